@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+)
+
+type AdminOptions struct {
+	// /health, /status and /debug/vars are served on this port, 0 disables it
+	Port int `yaml:"port"`
+}
+
+// nodeStatus is what /status and the status var of /debug/vars show.
+type nodeStatus struct {
+	WorkerId     int64 `json:"workerId"`
+	DatacenterId int64 `json:"datacenterId"`
+	// fencing epoch of the worker id claim, 0 without a registry
+	Epoch      int64  `json:"epoch,omitempty"`
+	Registry   string `json:"registry,omitempty"`
+	Registered bool   `json:"registered"`
+	Serving    bool   `json:"serving"`
+	// endpoint of the coordinator, when one is elected
+	Coordinator string `json:"coordinator,omitempty"`
+	// why no ids are issued
+	Unavailable string `json:"unavailable,omitempty"`
+}
+
+func (s nodeStatus) healthy() bool {
+	return s.Serving && (s.Registry == "" || s.Registered)
+}
+
+// newAdminServer serves the state of the node for load balancers and
+// monitoring: /health answers OK or 503 with the reason, /status the
+// nodeStatus as JSON and /debug/vars the counters. The coordinator answers
+// there too.
+func newAdminServer(options AdminOptions, handler *IdGeneratorHandler, registry Registry, claim *workerClaim, coordinator *coordinator, kind string) *http.Server {
+	status := func() nodeStatus {
+		s := nodeStatus{WorkerId: handler.workerId, DatacenterId: handler.datacenterId, Registry: kind}
+		s.Unavailable = handler.Unavailable()
+		s.Serving = s.Unavailable == ""
+		if registry != nil {
+			s.Registered = registry.Registered()
+		}
+		if claim != nil {
+			s.Epoch = claim.Epoch()
+		}
+		if coordinator != nil {
+			s.Coordinator = coordinator.Coordinator()
+		}
+		return s
+	}
+	expvar.Publish("status", expvar.Func(func() interface{} { return status() }))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		s := status()
+		if s.healthy() {
+			fmt.Fprintln(w, "OK")
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if !s.Serving {
+			fmt.Fprintln(w, s.Unavailable)
+		}
+		if s.Registry != "" && !s.Registered {
+			fmt.Fprintf(w, "not registered with %s\n", s.Registry)
+		}
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status())
+	})
+	mux.Handle("/debug/vars", expvar.Handler())
+	if coordinator != nil {
+		coordinator.handle(mux)
+	}
+	return &http.Server{Addr: fmt.Sprintf(":%d", options.Port), Handler: mux}
+}
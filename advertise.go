@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+type AdvertiseOptions struct {
+	// registered instead of an address of this host
+	Host string `yaml:"host"`
+	// the interface to take the address from, any if empty
+	Interface string `yaml:"interface"`
+	// networks the address is taken from, in order of preference
+	CIDRs stringList `yaml:"cidrs"`
+	// more endpoints of the node, name=port or name=host:port
+	Endpoints stringList `yaml:"endpoints"`
+}
+
+// the private networks, as addresses on 10., 172. and 192.168. used to be
+var defaultAdvertiseCIDRs = stringList{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+
+// advertiseHost returns the host the node registers, the first address in
+// the first of the networks of the options found on an interface which is
+// up. Link local addresses are left out, they are useless without a zone.
+func advertiseHost(options AdvertiseOptions) (string, error) {
+	if options.Host != "" {
+		return options.Host, nil
+	}
+	networks, err := parseCIDRs(options.CIDRs)
+	if err != nil {
+		return "", err
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("cannot list interfaces: %v", err)
+	}
+	var ips []net.IP
+	found := false
+	for _, iface := range ifaces {
+		if options.Interface != "" && iface.Name != options.Interface ||
+			options.Interface == "" && iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		found = true
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return "", fmt.Errorf("cannot list addresses of %s: %v", iface.Name, err)
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLinkLocalUnicast() {
+				ips = append(ips, ipNet.IP)
+			}
+		}
+	}
+	for _, network := range networks {
+		for _, ip := range ips {
+			if network.Contains(ip) {
+				infof("advertising local IP %s", ip)
+				return ip.String(), nil
+			}
+		}
+	}
+	if options.Interface != "" && !found {
+		return "", fmt.Errorf("no interface %s", options.Interface)
+	} else if options.Interface != "" {
+		return "", fmt.Errorf("no address of interface %s in %v", options.Interface, options.CIDRs)
+	}
+	return "", fmt.Errorf("no local address in %v, set the advertised host, interface or networks", options.CIDRs)
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// additionalEndpoints returns the http and admin endpoints, when they are
+// enabled, and those of the options, which may replace them. A port alone
+// is on host.
+func additionalEndpoints(config *Config, host string) (map[string]serversetEndpoint, error) {
+	endpoints := map[string]serversetEndpoint{}
+	if config.HTTP.Port > 0 {
+		endpoints["http"] = serversetEndpoint{Host: host, Port: config.HTTP.Port}
+	}
+	if config.Admin.Port > 0 {
+		endpoints["admin"] = serversetEndpoint{Host: host, Port: config.Admin.Port}
+	}
+	for _, endpoint := range config.Advertise.Endpoints {
+		parts := strings.SplitN(endpoint, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("endpoint %q must be name=port or name=host:port", endpoint)
+		}
+		endpointHost, portString := host, parts[1]
+		if strings.Contains(parts[1], ":") {
+			var err error
+			if endpointHost, portString, err = net.SplitHostPort(parts[1]); err != nil {
+				return nil, fmt.Errorf("endpoint %q: %v", endpoint, err)
+			}
+		}
+		port, err := strconv.Atoi(portString)
+		if err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("endpoint %q has an invalid port", endpoint)
+		}
+		endpoints[parts[0]] = serversetEndpoint{Host: endpointHost, Port: port}
+	}
+	return endpoints, nil
+}
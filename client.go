@@ -3,32 +3,111 @@ package main
 import (
 	"fmt"
 	"net"
-	"os"
+	"strconv"
+	"time"
 
 	"git.apache.org/thrift.git/lib/go/thrift"
 	"github.com/liusf/idgenerator/gen-go/idgenerator"
 )
 
-func newIdGeneratorClient(host string, port int) (timestamp int64, datacenterId int64, workerId int64) {
-	trans, err := thrift.NewTSocket(net.JoinHostPort(host, fmt.Sprint(port)))
+// openPeer connects to a peer, with TLS if certs are given, and timeout limits
+// the connect and every read and write.
+func openPeer(host string, port int, certs *certReloader, timeout time.Duration) (thrift.TTransport, error) {
+	var trans thrift.TTransport
+	var err error
+	if certs != nil {
+		trans, err = thrift.NewTSSLSocketTimeout(net.JoinHostPort(host, fmt.Sprint(port)), certs.ClientConfig(), timeout)
+	} else {
+		trans, err = thrift.NewTSocketTimeout(net.JoinHostPort(host, fmt.Sprint(port)), timeout)
+	}
 	if err != nil {
-		fmt.Printf("Error resolving address %s:%d, %v\n", host, port, err)
-		os.Exit(1)
+		return nil, fmt.Errorf("Error resolving address %s:%d, %v", host, port, err)
 	}
-	framedTransport := thrift.NewTFramedTransport(trans)
-	protocolFactory := thrift.NewTBinaryProtocolFactoryDefault()
-	client := idgenerator.NewIdGeneratorClientFactory(framedTransport, protocolFactory)
+	trans = thrift.NewTFramedTransport(trans)
 	if err := trans.Open(); err != nil {
-		fmt.Println("Error opening socket to ", host, ":", port, " ", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("Error opening socket to %s:%d %v", host, port, err)
+	}
+	return trans, nil
+}
+
+// queryPeers queries the peers at addrs (host:port) and returns those which
+// answered and the addresses of those which did not.
+func queryPeers(addrs []string, certs *certReloader, options SanityCheckOptions) ([]*peerClock, []string) {
+	var peers []*peerClock
+	var unreachable []string
+	for _, addr := range addrs {
+		host, portString, err := net.SplitHostPort(addr)
+		port, err2 := strconv.Atoi(portString)
+		if err != nil || err2 != nil {
+			warnf("port error %s", addr)
+			unreachable = append(unreachable, addr)
+			continue
+		}
+		peer, err := queryPeer(host, port, certs, options.ClockSamples, options.PeerTimeout)
+		if err != nil {
+			warnf("skipping peer %s: %v", addr, err)
+			unreachable = append(unreachable, addr)
+			continue
+		}
+		peers = append(peers, peer)
 	}
-	timestamp, err1 := client.GetTimestamp()
-	peerDatacenterId, err2 := client.GetDatacenterId()
-	peerWorkerId, err3 := client.GetWorkerId()
-	if err1 != nil || err2 != nil || err3 != nil {
-		fmt.Printf("Could not talk to peer %s:%d\n", host, port)
-		os.Exit(1)
+	return peers, unreachable
+}
+
+// queryPeer asks a peer for its ids and samples its clock with one getInfo
+// call per sample, or getTimestamp calls for peers without IdGeneratorV2.
+func queryPeer(host string, port int, certs *certReloader, samples int, timeout time.Duration) (*peerClock, error) {
+	trans, err := openPeer(host, port, certs, timeout)
+	if err != nil {
+		return nil, err
 	}
 	defer trans.Close()
-	return timestamp, peerDatacenterId, peerWorkerId
+	protocol := thrift.NewTBinaryProtocolTransport(trans)
+	client := idgenerator.NewIdGeneratorV2ClientProtocol(trans, protocol, thrift.NewTMultiplexedProtocol(protocol, "IdGeneratorV2"))
+	peer := &peerClock{addr: net.JoinHostPort(host, fmt.Sprint(port))}
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		info, err := client.GetInfo()
+		end := time.Now()
+		if x, ok := err.(thrift.TApplicationException); ok && x.TypeId() == thrift.UNKNOWN_METHOD && i == 0 {
+			return queryLegacyPeer(host, port, certs, samples, timeout)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Could not talk to peer %s:%d %v", host, port, err)
+		}
+		peer.datacenterId = info.DatacenterId
+		peer.workerId = info.WorkerId
+		peer.samples = append(peer.samples, newClockSample(start, end, info.Timestamp))
+	}
+	peer.estimate()
+	return peer, nil
+}
+
+// queryLegacyPeer is queryPeer for peers serving IdGenerator only, which close
+// the connection after an unknown method.
+func queryLegacyPeer(host string, port int, certs *certReloader, samples int, timeout time.Duration) (*peerClock, error) {
+	trans, err := openPeer(host, port, certs, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer trans.Close()
+	client := idgenerator.NewIdGeneratorClientFactory(trans, thrift.NewTBinaryProtocolFactoryDefault())
+	peer := &peerClock{addr: net.JoinHostPort(host, fmt.Sprint(port))}
+	var err1, err2 error
+	peer.datacenterId, err1 = client.GetDatacenterId()
+	peer.workerId, err2 = client.GetWorkerId()
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("Could not talk to peer %s:%d", host, port)
+	}
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		timestamp, err := client.GetTimestamp()
+		end := time.Now()
+		if err != nil {
+			return nil, fmt.Errorf("Could not talk to peer %s:%d %v", host, port, err)
+		}
+		peer.samples = append(peer.samples, newClockSample(start, end, timestamp))
+	}
+	peer.estimate()
+	return peer, nil
 }
@@ -0,0 +1,303 @@
+// Package client calls an id generator server over IdGeneratorV2, with a
+// pool of connections shared by goroutines.
+//
+//	c, err := client.New("10.0.0.5:3456", client.Options{})
+//	id, err := c.GetId(ctx, "order")
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+// ErrClosed is returned by calls after Close.
+var ErrClosed = errors.New("idgenerator: client is closed")
+
+type Options struct {
+	// maximum time to connect, 3s if 0
+	DialTimeout time.Duration
+	// maximum time to send a request and read its answer, 10s if 0, a
+	// shorter deadline of the context takes precedence
+	ReadTimeout time.Duration
+	// connections kept open between calls, 4 if 0
+	MaxIdle int
+	// maximum connections open at once, calls wait for one to be free, 0 for
+	// no limit
+	MaxConns int
+	// connect with TLS, as to servers started with -tls-cert
+	TLSConfig *tls.Config
+}
+
+// Info is what a server tells about itself.
+type Info struct {
+	WorkerId     int64
+	DatacenterId int64
+	// milliseconds since 1970 by the clock of the server
+	Timestamp int64
+	Scopes    []string
+}
+
+// Client calls one server. It is safe for concurrent use, each call takes a
+// connection of the pool and gives it back unless it failed.
+type Client struct {
+	addr    string
+	options Options
+	// holds a token per open connection when the connections are limited
+	slots  chan bool
+	idle   []*conn
+	closed bool
+	mux    sync.Mutex
+}
+
+// a TSocket or TSSLSocket
+type timeoutSocket interface {
+	thrift.TTransport
+	SetTimeout(time.Duration) error
+	// closes the connection without changing the socket
+	Interrupt() error
+}
+
+type conn struct {
+	socket timeoutSocket
+	trans  thrift.TTransport
+	client *idgenerator.IdGeneratorV2Client
+	// IdGenerator, the default service of the connection
+	legacy *idgenerator.IdGeneratorClient
+}
+
+// New returns a client of the server at addr (host:port), which connects
+// when it is first called.
+func New(addr string, options Options) (*Client, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, err
+	}
+	if options.DialTimeout == 0 {
+		options.DialTimeout = 3 * time.Second
+	}
+	if options.ReadTimeout == 0 {
+		options.ReadTimeout = 10 * time.Second
+	}
+	if options.MaxIdle == 0 {
+		options.MaxIdle = 4
+	}
+	c := &Client{addr: addr, options: options}
+	if options.MaxConns > 0 {
+		c.slots = make(chan bool, options.MaxConns)
+	}
+	return c, nil
+}
+
+// Addr returns the address of the server.
+func (c *Client) Addr() string {
+	return c.addr
+}
+
+// GetId returns an id of scope. The empty scope, which IdGeneratorV2
+// refuses, is asked for with getId of IdGenerator.
+func (c *Client) GetId(ctx context.Context, scope string) (int64, error) {
+	if scope == "" {
+		var id int64
+		err := c.call(ctx, func(conn *conn) (err error) {
+			id, err = conn.legacy.GetId(scope)
+			return err
+		})
+		return id, err
+	}
+	ids, err := c.GetIds(ctx, scope, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, errors.New("idgenerator: no id in the answer")
+	}
+	return ids[0], nil
+}
+
+// GetIds returns count ids of scope, in increasing order.
+func (c *Client) GetIds(ctx context.Context, scope string, count int) ([]int64, error) {
+	if count > math.MaxInt32 {
+		return nil, &InvalidRequestError{Message: fmt.Sprintf("wrong count %d", count)}
+	}
+	request := idgenerator.NewGetIdsRequest()
+	request.Scope = scope
+	request.Count = int32(count)
+	if deadline, ok := ctx.Deadline(); ok {
+		deadlineMs := deadline.UnixNano() / int64(time.Millisecond)
+		request.DeadlineMs = &deadlineMs
+	}
+	var response *idgenerator.GetIdsResponse
+	err := c.call(ctx, func(conn *conn) (err error) {
+		response, err = conn.client.GetIds(request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Ids, nil
+}
+
+// Info asks the server for its ids, clock and scopes.
+func (c *Client) Info(ctx context.Context) (*Info, error) {
+	var info *idgenerator.ServerInfo
+	err := c.call(ctx, func(conn *conn) (err error) {
+		info, err = conn.client.GetInfo()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Info{WorkerId: info.WorkerId, DatacenterId: info.DatacenterId, Timestamp: info.Timestamp, Scopes: info.Scopes}, nil
+}
+
+// Close closes the idle connections, those in use are closed when their
+// calls return.
+func (c *Client) Close() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.closed = true
+	for _, conn := range c.idle {
+		conn.trans.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+// call runs f with a connection of the pool. The connection is closed when
+// ctx is done before f returns, and dropped after any error but the
+// exceptions of the server.
+func (c *Client) call(ctx context.Context, f func(*conn) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	conn, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+	conn.socket.SetTimeout(timeout(ctx, c.options.ReadTimeout))
+	var done, stopped chan bool
+	if ctx.Done() != nil {
+		done = make(chan bool)
+		stopped = make(chan bool)
+		go func() {
+			defer close(stopped)
+			select {
+			case <-ctx.Done():
+				// only the net.Conn is safe to close while f uses it
+				conn.socket.Interrupt()
+			case <-done:
+			}
+		}()
+	}
+	err = f(conn)
+	if done != nil {
+		close(done)
+		<-stopped
+	}
+	if ctx.Err() != nil {
+		// the connection may have been closed under f
+		c.put(conn, false)
+		if err != nil {
+			return ctx.Err()
+		}
+		return nil
+	}
+	err = convert(err)
+	c.put(conn, err == nil || isServerError(err))
+	return err
+}
+
+// get takes an idle connection or opens one, waiting for a free slot if the
+// connections are limited.
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	if c.slots != nil {
+		select {
+		case c.slots <- true:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c.mux.Lock()
+	if c.closed {
+		c.mux.Unlock()
+		c.release()
+		return nil, ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mux.Unlock()
+		return conn, nil
+	}
+	c.mux.Unlock()
+	conn, err := c.dial(timeout(ctx, c.options.DialTimeout))
+	if err != nil {
+		c.release()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// put gives conn back to the pool if it can be used again and there is room.
+func (c *Client) put(conn *conn, reusable bool) {
+	c.mux.Lock()
+	if reusable && !c.closed && len(c.idle) < c.options.MaxIdle {
+		c.idle = append(c.idle, conn)
+	} else {
+		conn.trans.Close()
+	}
+	c.mux.Unlock()
+	c.release()
+}
+
+func (c *Client) release() {
+	if c.slots != nil {
+		<-c.slots
+	}
+}
+
+func (c *Client) dial(timeout time.Duration) (*conn, error) {
+	var socket timeoutSocket
+	var err error
+	if c.options.TLSConfig != nil {
+		socket, err = thrift.NewTSSLSocketTimeout(c.addr, c.options.TLSConfig, timeout)
+	} else {
+		socket, err = thrift.NewTSocketTimeout(c.addr, timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	trans := thrift.NewTFramedTransport(socket)
+	if err := trans.Open(); err != nil {
+		return nil, err
+	}
+	protocol := thrift.NewTBinaryProtocolTransport(trans)
+	return &conn{
+		socket: socket,
+		trans:  trans,
+		client: idgenerator.NewIdGeneratorV2ClientProtocol(trans, protocol, thrift.NewTMultiplexedProtocol(protocol, "IdGeneratorV2")),
+		legacy: idgenerator.NewIdGeneratorClientProtocol(trans, protocol, protocol),
+	}, nil
+}
+
+// timeout is limit, or the time left until the deadline of ctx if it is
+// sooner.
+func timeout(ctx context.Context, limit time.Duration) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); left < limit {
+			if left <= 0 {
+				return time.Millisecond
+			}
+			return left
+		}
+	}
+	return limit
+}
@@ -0,0 +1,342 @@
+package client
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+// testServer serves IdGeneratorV2 multiplexed and IdGenerator by default, as
+// the id generator does, and counts its connections. Ids of the scope "slow"
+// are answered after release is closed, those of "exhausted" with
+// SequenceExhausted and those of "unavailable" with ServiceUnavailable.
+type testServer struct {
+	listener net.Listener
+	// workerId the server tells, and adds to its ids times 1000
+	workerId int64
+	started  chan bool
+	release  chan bool
+	accepted int32
+	// getIds calls answered
+	calls int32
+	conns map[net.Conn]bool
+	mux   sync.Mutex
+}
+
+func startTestServer(t *testing.T, workerId int64) *testServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{listener: listener, workerId: workerId, started: make(chan bool, 16), release: make(chan bool), conns: make(map[net.Conn]bool)}
+	processor := thrift.NewTMultiplexedProcessor()
+	processor.RegisterProcessor("IdGeneratorV2", idgenerator.NewIdGeneratorV2Processor(s))
+	processor.RegisterDefault(idgenerator.NewIdGeneratorProcessor(s))
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&s.accepted, 1)
+			s.mux.Lock()
+			s.conns[conn] = true
+			s.mux.Unlock()
+			go s.serve(conn, processor)
+		}
+	}()
+	t.Cleanup(s.stop)
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, processor thrift.TProcessor) {
+	defer func() {
+		s.mux.Lock()
+		delete(s.conns, conn)
+		s.mux.Unlock()
+		conn.Close()
+	}()
+	trans := thrift.NewTFramedTransport(thrift.NewTSocketFromConnTimeout(conn, 0))
+	protocol := thrift.NewTBinaryProtocolTransport(trans)
+	for {
+		if ok, err := processor.Process(protocol, protocol); !ok || err != nil {
+			return
+		}
+	}
+}
+
+func (s *testServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+// open returns the number of connections open.
+func (s *testServer) open() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.conns)
+}
+
+// closeConns closes the connections open, as a server going away does.
+func (s *testServer) closeConns() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *testServer) stop() {
+	s.listener.Close()
+	s.closeConns()
+	select {
+	case <-s.release:
+	default:
+		close(s.release)
+	}
+}
+
+func (s *testServer) GetIds(request *idgenerator.GetIdsRequest) (*idgenerator.GetIdsResponse, error) {
+	atomic.AddInt32(&s.calls, 1)
+	switch request.Scope {
+	case "slow":
+		s.started <- true
+		<-s.release
+	case "exhausted":
+		return nil, &idgenerator.SequenceExhausted{Message: "Sequence exhausted for exhausted"}
+	case "unavailable":
+		return nil, &idgenerator.ServiceUnavailable{Message: "shutting down"}
+	}
+	response := idgenerator.NewGetIdsResponse()
+	for i := int32(1); i <= request.Count; i++ {
+		response.Ids = append(response.Ids, s.workerId*1000+int64(i))
+	}
+	return response, nil
+}
+
+func (s *testServer) GetInfo() (*idgenerator.ServerInfo, error) {
+	return &idgenerator.ServerInfo{WorkerId: s.workerId}, nil
+}
+
+func (s *testServer) GetWorkerId() (int64, error) {
+	return s.workerId, nil
+}
+
+func (s *testServer) GetTimestamp() (int64, error) {
+	return time.Now().UnixNano() / int64(time.Millisecond), nil
+}
+
+// GetId answers -1 to tell IdGenerator from IdGeneratorV2.
+func (s *testServer) GetId(scope string) (int64, error) {
+	return -1, nil
+}
+
+func (s *testServer) GetDatacenterId() (int64, error) {
+	return 0, nil
+}
+
+func (s *testServer) GetScopes() ([]string, error) {
+	return nil, nil
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !condition(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func idle(c *Client) int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return len(c.idle)
+}
+
+func TestClientGetIds(t *testing.T) {
+	server := startTestServer(t, 1)
+	c, _ := New(server.addr(), Options{})
+	defer c.Close()
+	ctx := context.Background()
+
+	if ids, err := c.GetIds(ctx, "ORDER", 3); err != nil || len(ids) != 3 || ids[0] != 1001 || ids[2] != 1003 {
+		t.Fatalf("getIds returned %v: %v", ids, err)
+	}
+	if id, err := c.GetId(ctx, "ORDER"); err != nil || id != 1001 {
+		t.Fatalf("getId returned %d: %v", id, err)
+	}
+	// IdGeneratorV2 refuses the empty scope
+	if id, err := c.GetId(ctx, ""); err != nil || id != -1 {
+		t.Fatalf("getId of the empty scope returned %d: %v", id, err)
+	}
+	if strconv.IntSize == 64 {
+		count := math.MaxInt32
+		count++
+		if _, err := c.GetIds(ctx, "ORDER", count); err == nil {
+			t.Fatal("getIds of more than MaxInt32 ids")
+		} else if _, ok := err.(*InvalidRequestError); !ok {
+			t.Fatalf("getIds of more than MaxInt32 ids, error %#v", err)
+		}
+	}
+	if info, err := c.Info(ctx); err != nil || info.WorkerId != 1 {
+		t.Fatalf("info returned %+v: %v", info, err)
+	}
+	// one after the other, the calls share a connection
+	if accepted := atomic.LoadInt32(&server.accepted); accepted != 1 {
+		t.Fatalf("%d connections for calls one after the other", accepted)
+	}
+}
+
+func TestClientMaxIdle(t *testing.T) {
+	server := startTestServer(t, 1)
+	c, _ := New(server.addr(), Options{MaxIdle: 1})
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetId(context.Background(), "slow"); err != nil {
+				t.Errorf("getId: %v", err)
+			}
+		}()
+	}
+	<-server.started
+	<-server.started
+	close(server.release)
+	wg.Wait()
+	// the connection found no room among the idle ones is closed
+	if n := idle(c); n != 1 {
+		t.Fatalf("%d idle connections, at most 1 expected", n)
+	}
+	waitFor(t, "the second connection to be closed", func() bool { return server.open() == 1 })
+	if _, err := c.GetId(context.Background(), "ORDER"); err != nil {
+		t.Fatal(err)
+	}
+	if accepted := atomic.LoadInt32(&server.accepted); accepted != 2 {
+		t.Fatalf("%d connections, the idle one was not used again", accepted)
+	}
+}
+
+func TestClientMaxConns(t *testing.T) {
+	server := startTestServer(t, 1)
+	c, _ := New(server.addr(), Options{MaxConns: 1})
+	defer c.Close()
+
+	done := make(chan error)
+	go func() {
+		_, err := c.GetId(context.Background(), "slow")
+		done <- err
+	}()
+	<-server.started
+	// waits for the slot until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetId(ctx, "ORDER"); err != context.DeadlineExceeded {
+		t.Fatalf("getId with the connection in use, error %v", err)
+	}
+	// and gets it when the call in progress returns
+	second := make(chan error)
+	go func() {
+		_, err := c.GetId(context.Background(), "ORDER")
+		second <- err
+	}()
+	close(server.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-second; err != nil {
+		t.Fatal(err)
+	}
+	if accepted := atomic.LoadInt32(&server.accepted); accepted != 1 {
+		t.Fatalf("%d connections, at most 1 expected", accepted)
+	}
+}
+
+func TestClientCancel(t *testing.T) {
+	server := startTestServer(t, 1)
+	c, _ := New(server.addr(), Options{MaxConns: 1})
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := c.GetId(ctx, "slow")
+		done <- err
+	}()
+	<-server.started
+	start := time.Now()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("cancelled getId, error %v", err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("cancelled getId returned after %v", took)
+	}
+	// the connection is closed, not given back with the answer still to come
+	if n := idle(c); n != 0 {
+		t.Fatalf("%d idle connections after the cancelled call", n)
+	}
+	close(server.release)
+	waitFor(t, "the connection of the cancelled call to be closed", func() bool { return server.open() == 0 })
+	// and its slot is free
+	if _, err := c.GetId(context.Background(), "ORDER"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientTransportError(t *testing.T) {
+	server := startTestServer(t, 1)
+	c, _ := New(server.addr(), Options{})
+	defer c.Close()
+	ctx := context.Background()
+
+	if _, err := c.GetId(ctx, "ORDER"); err != nil {
+		t.Fatal(err)
+	}
+	server.closeConns()
+	waitFor(t, "the connection to be closed", func() bool { return server.open() == 0 })
+	if _, err := c.GetId(ctx, "ORDER"); err == nil || isServerError(err) {
+		t.Fatalf("getId on a closed connection, error %v", err)
+	}
+	if n := idle(c); n != 0 {
+		t.Fatalf("%d idle connections after a transport error", n)
+	}
+	if _, err := c.GetId(ctx, "ORDER"); err != nil {
+		t.Fatal(err)
+	}
+	if accepted := atomic.LoadInt32(&server.accepted); accepted != 2 {
+		t.Fatalf("%d connections, expected a new one after the transport error", accepted)
+	}
+}
+
+func TestClientServerError(t *testing.T) {
+	server := startTestServer(t, 1)
+	c, _ := New(server.addr(), Options{})
+	defer c.Close()
+	ctx := context.Background()
+
+	if _, err := c.GetId(ctx, "exhausted"); err == nil {
+		t.Fatal("getId of exhausted")
+	} else if e, ok := err.(*SequenceExhaustedError); !ok || e.Message != "Sequence exhausted for exhausted" {
+		t.Fatalf("getId of exhausted, error %#v", err)
+	}
+	if n := idle(c); n != 1 {
+		t.Fatalf("%d idle connections after an exception of the server", n)
+	}
+	if _, err := c.GetId(ctx, "ORDER"); err != nil {
+		t.Fatal(err)
+	}
+	if accepted := atomic.LoadInt32(&server.accepted); accepted != 1 {
+		t.Fatalf("%d connections, the connection was not kept after the exception", accepted)
+	}
+}
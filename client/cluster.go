@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoMembers is returned when the cluster has no member to call.
+var ErrNoMembers = errors.New("idgenerator: no servers")
+
+// Balancer chooses the member of a cluster which is called.
+type Balancer int
+
+const (
+	// RoundRobin calls the members in turn.
+	RoundRobin Balancer = iota
+	// LeastOutstanding calls the member with the fewest calls in progress.
+	LeastOutstanding
+)
+
+type ClusterOptions struct {
+	// options of the client of each member
+	Options
+	Balancer Balancer
+	// zookeeper session timeout, 10s if 0
+	SessionTimeout time.Duration
+	// logs of the zookeeper client and problems reading the serverset,
+	// discarded if nil
+	Logger Logger
+}
+
+// memberSource tells the members of a cluster as they change.
+type memberSource interface {
+	// run calls update with the members whenever they change, starting with
+	// addrs, until the source is closed.
+	run(addrs []string, update func([]string))
+	close()
+}
+
+// Cluster calls the members of a cluster, read from its serverset in
+// zookeeper as they come and go or given as a list. A call failing to
+// connect or with ClockMovedBackwards or ServiceUnavailable is made again
+// with another member, each member is tried once. Cluster is safe for
+// concurrent use.
+type Cluster struct {
+	// advanced by every pick, first for 64 bit alignment
+	next    uint64
+	options ClusterOptions
+	// nil for a list of members
+	source  memberSource
+	members []*member
+	closed  bool
+	mux     sync.RWMutex
+}
+
+type member struct {
+	// calls in progress, first for 64 bit alignment
+	outstanding int64
+	client      *Client
+}
+
+// NewCluster connects to the cluster at dest, a serverset in the form of
+// finagle, zk!host:port,host:port!/service/idgenerators, or a list of
+// host:port,host:port.
+func NewCluster(dest string, options ClusterOptions) (*Cluster, error) {
+	parts := strings.Split(dest, "!")
+	if len(parts) == 1 {
+		return newCluster(strings.Split(dest, ","), nil, options)
+	}
+	if len(parts) != 3 || parts[0] != "zk" && parts[0] != "zk2" || parts[1] == "" || !strings.HasPrefix(parts[2], "/") {
+		return nil, fmt.Errorf("idgenerator: %q must be zk!host:port,..!/path or host:port,..", dest)
+	}
+	return NewZkCluster(strings.Split(parts[1], ","), parts[2], options)
+}
+
+// NewZkCluster watches the serverset at path, e.g. /service/idgenerators,
+// in the zookeepers at servers.
+func NewZkCluster(servers []string, path string, options ClusterOptions) (*Cluster, error) {
+	if options.SessionTimeout == 0 {
+		options.SessionTimeout = 10 * time.Second
+	}
+	if options.Logger == nil {
+		options.Logger = nopLogger{}
+	}
+	serverset, err := newZkServerset(servers, path, options)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := serverset.members()
+	if err != nil {
+		serverset.close()
+		return nil, fmt.Errorf("idgenerator: cannot read serverset %s: %v", path, err)
+	}
+	return newCluster(addrs, serverset, options)
+}
+
+// newCluster calls the members addrs, and those source tells after, closing
+// source if it fails.
+func newCluster(addrs []string, source memberSource, options ClusterOptions) (*Cluster, error) {
+	c := &Cluster{options: options, source: source}
+	if err := c.update(addrs); err != nil {
+		if source != nil {
+			source.close()
+		}
+		return nil, err
+	}
+	if source != nil {
+		go source.run(addrs, func(addrs []string) {
+			c.update(addrs)
+		})
+	}
+	return c, nil
+}
+
+// update replaces the members, keeping the clients of those still there. An
+// address is there twice while a restarted server is registered again
+// before its old session expired.
+func (c *Cluster) update(addrs []string) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.closed {
+		return nil
+	}
+	current := make(map[string]*member)
+	for _, m := range c.members {
+		current[m.client.Addr()] = m
+	}
+	var members []*member
+	added := make(map[string]bool)
+	for _, addr := range addrs {
+		if added[addr] {
+			continue
+		}
+		added[addr] = true
+		if m, found := current[addr]; found {
+			members = append(members, m)
+			delete(current, addr)
+			continue
+		}
+		client, err := New(addr, c.options.Options)
+		if err != nil {
+			return err
+		}
+		members = append(members, &member{client: client})
+	}
+	for _, m := range current {
+		m.client.Close()
+	}
+	c.members = members
+	return nil
+}
+
+// Members returns the addresses of the members.
+func (c *Cluster) Members() []string {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	addrs := make([]string, len(c.members))
+	for i, m := range c.members {
+		addrs[i] = m.client.Addr()
+	}
+	return addrs
+}
+
+// GetId returns an id of scope.
+func (c *Cluster) GetId(ctx context.Context, scope string) (int64, error) {
+	var id int64
+	err := c.call(ctx, func(client *Client) (err error) {
+		id, err = client.GetId(ctx, scope)
+		return err
+	})
+	return id, err
+}
+
+// GetIds returns count ids of scope, in increasing order.
+func (c *Cluster) GetIds(ctx context.Context, scope string, count int) ([]int64, error) {
+	var ids []int64
+	err := c.call(ctx, func(client *Client) (err error) {
+		ids, err = client.GetIds(ctx, scope, count)
+		return err
+	})
+	return ids, err
+}
+
+// Close stops watching the serverset and closes the connections.
+func (c *Cluster) Close() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.source != nil {
+		c.source.close()
+	}
+	for _, m := range c.members {
+		m.client.Close()
+	}
+	c.members = nil
+	return nil
+}
+
+// call runs f with the member picked by the balancer, and with the next one
+// while it fails in a way another member may not.
+func (c *Cluster) call(ctx context.Context, f func(*Client) error) error {
+	tried := make(map[*member]bool)
+	var err error
+	for {
+		m, closed := c.pick(tried)
+		if closed {
+			return ErrClosed
+		}
+		if m == nil {
+			if err != nil {
+				return err
+			}
+			return ErrNoMembers
+		}
+		atomic.AddInt64(&m.outstanding, 1)
+		err = f(m.client)
+		atomic.AddInt64(&m.outstanding, -1)
+		if err == nil || ctx.Err() != nil || !failover(err) {
+			return err
+		}
+		tried[m] = true
+	}
+}
+
+// failover tells whether another member may succeed after err, which is
+// also ErrClosed from a member which just left.
+func failover(err error) bool {
+	switch err.(type) {
+	case *SequenceExhaustedError, *InvalidScopeError, *InvalidRequestError:
+		return false
+	}
+	return true
+}
+
+// pick returns a member not tried yet, nil if there is none, or tells that
+// the cluster is closed.
+func (c *Cluster) pick(tried map[*member]bool) (*member, bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	if c.closed {
+		return nil, true
+	}
+	n := len(c.members)
+	if n == 0 {
+		return nil, false
+	}
+	// least outstanding starts at the next member too, so ties are spread
+	start := int(atomic.AddUint64(&c.next, 1) % uint64(n))
+	var picked *member
+	for i := 0; i < n; i++ {
+		m := c.members[(start+i)%n]
+		if tried[m] {
+			continue
+		}
+		if c.options.Balancer == RoundRobin {
+			return m, false
+		}
+		if picked == nil || atomic.LoadInt64(&m.outstanding) < atomic.LoadInt64(&picked.outstanding) {
+			picked = m
+		}
+	}
+	return picked, false
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeSource tells the members sent to changes, as a serverset does.
+type fakeSource struct {
+	changes chan []string
+	closed  chan bool
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{changes: make(chan []string), closed: make(chan bool)}
+}
+
+func (s *fakeSource) run(addrs []string, update func([]string)) {
+	for {
+		select {
+		case addrs := <-s.changes:
+			update(addrs)
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+func (s *fakeSource) close() {
+	close(s.closed)
+}
+
+// startCluster starts servers with the worker ids 1 to n and a cluster of
+// them.
+func startCluster(t *testing.T, n int, balancer Balancer) ([]*testServer, *fakeSource, *Cluster) {
+	var servers []*testServer
+	var addrs []string
+	for i := 1; i <= n; i++ {
+		server := startTestServer(t, int64(i))
+		servers = append(servers, server)
+		addrs = append(addrs, server.addr())
+	}
+	source := newFakeSource()
+	c, err := newCluster(addrs, source, ClusterOptions{Balancer: balancer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return servers, source, c
+}
+
+// workerIds makes calls getId calls and counts them by the worker id of the
+// server answering.
+func workerIds(t *testing.T, c *Cluster, calls int) map[int64]int {
+	t.Helper()
+	answered := make(map[int64]int)
+	for i := 0; i < calls; i++ {
+		id, err := c.GetId(context.Background(), "ORDER")
+		if err != nil {
+			t.Fatal(err)
+		}
+		answered[id/1000]++
+	}
+	return answered
+}
+
+func TestClusterRoundRobin(t *testing.T) {
+	_, _, c := startCluster(t, 3, RoundRobin)
+	if answered := workerIds(t, c, 9); !reflect.DeepEqual(answered, map[int64]int{1: 3, 2: 3, 3: 3}) {
+		t.Fatalf("calls answered by %v, expected 3 by each", answered)
+	}
+}
+
+func TestClusterLeastOutstanding(t *testing.T) {
+	servers, _, c := startCluster(t, 2, LeastOutstanding)
+	done := make(chan error)
+	go func() {
+		_, err := c.GetId(context.Background(), "slow")
+		done <- err
+	}()
+	var busy int64
+	select {
+	case <-servers[0].started:
+		busy = 1
+	case <-servers[1].started:
+		busy = 2
+	}
+	// all go to the member without a call in progress
+	if answered := workerIds(t, c, 4); answered[busy] != 0 {
+		t.Fatalf("calls answered by %v while %d has a call in progress", answered, busy)
+	}
+	close(servers[busy-1].release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// and are spread again once it returned
+	if answered := workerIds(t, c, 4); answered[1] != 2 || answered[2] != 2 {
+		t.Fatalf("calls answered by %v, expected 2 by each", answered)
+	}
+}
+
+func TestClusterMembersChange(t *testing.T) {
+	servers, source, c := startCluster(t, 2, RoundRobin)
+	workerIds(t, c, 2)
+
+	// a member leaves, its connections are closed
+	source.changes <- []string{servers[1].addr()}
+	waitFor(t, "the member to leave", func() bool { return reflect.DeepEqual(c.Members(), []string{servers[1].addr()}) })
+	waitFor(t, "the connections of the member left to be closed", func() bool { return servers[0].open() == 0 })
+	if answered := workerIds(t, c, 4); !reflect.DeepEqual(answered, map[int64]int{2: 4}) {
+		t.Fatalf("calls answered by %v after 1 left", answered)
+	}
+
+	// another joins, the connection to the member staying is kept
+	third := startTestServer(t, 3)
+	source.changes <- []string{servers[1].addr(), third.addr()}
+	waitFor(t, "the member to join", func() bool { return len(c.Members()) == 2 })
+	if answered := workerIds(t, c, 4); !reflect.DeepEqual(answered, map[int64]int{2: 2, 3: 2}) {
+		t.Fatalf("calls answered by %v after 3 joined", answered)
+	}
+	if accepted := atomic.LoadInt32(&servers[1].accepted); accepted != 1 {
+		t.Fatalf("%d connections to the member staying", accepted)
+	}
+
+	// none left
+	source.changes <- []string{}
+	waitFor(t, "the members to leave", func() bool { return len(c.Members()) == 0 })
+	if _, err := c.GetId(context.Background(), "ORDER"); err != ErrNoMembers {
+		t.Fatalf("getId without members, error %v", err)
+	}
+}
+
+func TestClusterFailover(t *testing.T) {
+	servers, _, c := startCluster(t, 3, RoundRobin)
+	// a member which went away without leaving the serverset is skipped
+	servers[0].stop()
+	if answered := workerIds(t, c, 6); answered[1] != 0 || answered[2]+answered[3] != 6 {
+		t.Fatalf("calls answered by %v with 1 stopped", answered)
+	}
+}
+
+func TestClusterFailoverErrors(t *testing.T) {
+	servers, _, c := startCluster(t, 2, RoundRobin)
+	calls := func() int32 {
+		return atomic.LoadInt32(&servers[0].calls) + atomic.LoadInt32(&servers[1].calls)
+	}
+
+	// ServiceUnavailable is tried with each member once
+	before := calls()
+	if _, err := c.GetId(context.Background(), "unavailable"); err == nil {
+		t.Fatal("getId with every member unavailable")
+	} else if _, ok := err.(*ServiceUnavailableError); !ok {
+		t.Fatalf("getId with every member unavailable, error %#v", err)
+	}
+	if n := calls() - before; n != 2 {
+		t.Fatalf("%d calls with every member unavailable, expected one to each", n)
+	}
+
+	// SequenceExhausted is returned right away
+	before = calls()
+	if _, err := c.GetId(context.Background(), "exhausted"); err == nil {
+		t.Fatal("getId of exhausted")
+	} else if _, ok := err.(*SequenceExhaustedError); !ok {
+		t.Fatalf("getId of exhausted, error %#v", err)
+	}
+	if n := calls() - before; n != 1 {
+		t.Fatalf("%d calls for a SequenceExhausted, expected 1", n)
+	}
+}
+
+func TestClusterList(t *testing.T) {
+	first := startTestServer(t, 1)
+	second := startTestServer(t, 2)
+	c, err := NewCluster(first.addr()+","+second.addr(), ClusterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	members := c.Members()
+	sort.Strings(members)
+	expected := []string{first.addr(), second.addr()}
+	sort.Strings(expected)
+	if !reflect.DeepEqual(members, expected) {
+		t.Fatalf("members %v, expected %v", members, expected)
+	}
+	if answered := workerIds(t, c, 2); answered[1] != 1 || answered[2] != 1 {
+		t.Fatalf("calls answered by %v", answered)
+	}
+	if _, err := NewCluster("zk!!/service/idgenerators", ClusterOptions{}); err == nil {
+		t.Fatal("cluster of a serverset without zookeeper servers")
+	}
+}
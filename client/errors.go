@@ -0,0 +1,86 @@
+package client
+
+import (
+	"time"
+
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+// ClockMovedBackwardsError is returned while the clock of the server is
+// behind the last timestamp it used, it may be asked again after RetryAfter
+// or another server right away.
+type ClockMovedBackwardsError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *ClockMovedBackwardsError) Error() string {
+	return "idgenerator: clock moved backwards: " + e.Message
+}
+
+// SequenceExhaustedError is returned when all sequence numbers of a
+// millisecond are used and the clock of the server did not move on in time.
+type SequenceExhaustedError struct {
+	Message string
+}
+
+func (e *SequenceExhaustedError) Error() string {
+	return "idgenerator: sequence exhausted: " + e.Message
+}
+
+// InvalidScopeError is returned for scopes the server does not issue ids for.
+type InvalidScopeError struct {
+	Message string
+	Scope   string
+}
+
+func (e *InvalidScopeError) Error() string {
+	return "idgenerator: invalid scope " + e.Scope + ": " + e.Message
+}
+
+// ServiceUnavailableError is returned while the server does not issue ids,
+// e.g. while shutting down, another server may.
+type ServiceUnavailableError struct {
+	Message string
+}
+
+func (e *ServiceUnavailableError) Error() string {
+	return "idgenerator: service unavailable: " + e.Message
+}
+
+// InvalidRequestError is returned for requests the server does not accept,
+// e.g. a count out of range.
+type InvalidRequestError struct {
+	Message string
+}
+
+func (e *InvalidRequestError) Error() string {
+	return "idgenerator: invalid request: " + e.Message
+}
+
+// convert replaces the exceptions of the server with the errors above.
+func convert(err error) error {
+	switch e := err.(type) {
+	case *idgenerator.ClockMovedBackwards:
+		return &ClockMovedBackwardsError{Message: e.Message, RetryAfter: time.Duration(e.RetryAfterMs) * time.Millisecond}
+	case *idgenerator.SequenceExhausted:
+		return &SequenceExhaustedError{Message: e.Message}
+	case *idgenerator.InvalidScope:
+		return &InvalidScopeError{Message: e.Message, Scope: e.Scope}
+	case *idgenerator.ServiceUnavailable:
+		return &ServiceUnavailableError{Message: e.Message}
+	case *idgenerator.InvalidRequest:
+		return &InvalidRequestError{Message: e.Message}
+	}
+	return err
+}
+
+// isServerError tells whether err is an exception of the server, after
+// which the connection can be used again.
+func isServerError(err error) bool {
+	switch err.(type) {
+	case *ClockMovedBackwardsError, *SequenceExhaustedError, *InvalidScopeError, *ServiceUnavailableError, *InvalidRequestError:
+		return true
+	}
+	return false
+}
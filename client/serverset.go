@@ -0,0 +1,46 @@
+package client
+
+import (
+	"github.com/liusf/idgenerator/internal/serverset"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// Logger is where the zookeeper client and the serverset watch log, a
+// *log.Logger will do.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(format string, args ...interface{}) {}
+
+// zkServerset tells the ALIVE members of a serverset in zookeeper, the
+// servers leave it or turn WARNING when they do not issue ids.
+type zkServerset struct {
+	conn   *zk.Conn
+	watch  *serverset.Watch
+	closed chan bool
+}
+
+func newZkServerset(servers []string, path string, options ClusterOptions) (*zkServerset, error) {
+	conn, _, err := zk.Connect(servers, options.SessionTimeout, zk.WithLogger(options.Logger))
+	if err != nil {
+		return nil, err
+	}
+	closed := make(chan bool)
+	return &zkServerset{conn: conn, watch: serverset.NewWatch(conn, path, options.Logger, closed), closed: closed}, nil
+}
+
+func (s *zkServerset) members() ([]string, error) {
+	return s.watch.Members()
+}
+
+func (s *zkServerset) run(addrs []string, update func([]string)) {
+	s.watch.Run(addrs, update)
+}
+
+func (s *zkServerset) close() {
+	close(s.closed)
+	s.conn.Close()
+}
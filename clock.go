@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// clockSample is one timestamp read from a peer. As in Cristian's algorithm
+// the peer is assumed to have read its clock halfway through the round trip.
+type clockSample struct {
+	offset time.Duration
+	rtt    time.Duration
+}
+
+func newClockSample(start time.Time, end time.Time, timestamp int64) clockSample {
+	rtt := end.Sub(start)
+	middle := start.Add(rtt / 2)
+	// the peer truncates to milliseconds, on average it is half a millisecond later
+	peer := time.Duration(timestamp)*time.Millisecond + time.Millisecond/2
+	return clockSample{offset: peer - time.Duration(middle.UnixNano()), rtt: rtt}
+}
+
+// peerClock is what the sanity check learns about a peer.
+type peerClock struct {
+	addr         string
+	datacenterId int64
+	workerId     int64
+	samples      []clockSample
+	// how far the clock of the peer is ahead of ours, and the shortest round
+	// trip it was measured with
+	offset time.Duration
+	rtt    time.Duration
+}
+
+// estimate takes the median offset of the half of the samples with the
+// shortest round trips, the others were likely delayed on one way only.
+func (p *peerClock) estimate() {
+	samples := append([]clockSample{}, p.samples...)
+	sort.Slice(samples, func(i, j int) bool { return samples[i].rtt < samples[j].rtt })
+	samples = samples[:(len(samples)+1)/2]
+	offsets := make([]time.Duration, len(samples))
+	for i, sample := range samples {
+		offsets[i] = sample.offset
+	}
+	p.offset = medianDuration(offsets)
+	p.rtt = samples[0].rtt
+}
+
+func (p *peerClock) String() string {
+	sign := "+"
+	if p.offset < 0 {
+		sign = ""
+	}
+	return fmt.Sprintf("%s %s%v (rtt %v)", p.addr, sign, p.offset.Round(time.Microsecond), p.rtt.Round(time.Microsecond))
+}
+
+func medianDuration(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// medianOffset is how far the clocks of the peers are ahead of ours, by the
+// peer in the middle.
+func medianOffset(peers []*peerClock) time.Duration {
+	offsets := make([]time.Duration, len(peers))
+	for i, peer := range peers {
+		offsets[i] = peer.offset
+	}
+	return medianDuration(offsets)
+}
+
+// checkClock compares our clock to the median of the peers in our datacenter
+// and separately, with the looser limit, to that of the peers in the others.
+func checkClock(peers []*peerClock, datacenterId int64, options SanityCheckOptions) error {
+	var local, remote []*peerClock
+	for _, peer := range peers {
+		if peer.datacenterId == datacenterId {
+			local = append(local, peer)
+		} else {
+			remote = append(remote, peer)
+		}
+	}
+	if err := checkOffset(local, options.MaxClockSkew, "the peers"); err != nil {
+		return err
+	}
+	return checkOffset(remote, options.MaxCrossDcClockSkew, "the peers in other datacenters")
+}
+
+func checkOffset(peers []*peerClock, maxSkew time.Duration, name string) error {
+	if len(peers) == 0 {
+		return nil
+	}
+	offset := medianOffset(peers)
+	if offset > maxSkew || -offset > maxSkew {
+		return fmt.Errorf("clock is %v away from the median of %s, more than %v. Peer offsets: %s",
+			offset.Round(time.Microsecond), name, maxSkew, describeOffsets(peers))
+	}
+	return nil
+}
+
+func describeOffsets(peers []*peerClock) string {
+	descriptions := make([]string, len(peers))
+	for i, peer := range peers {
+		descriptions[i] = peer.String()
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// checkGuard stops issuing ids for cause (clock, peers) while its check
+// fails and marks the node WARNING in the registry, both are undone once the
+// check passes, the status only if no other cause holds the ids back.
+type checkGuard struct {
+	cause    string
+	registry Registry
+	handler  *IdGeneratorHandler
+	failing  bool
+}
+
+func newCheckGuard(cause string, registry Registry, handler *IdGeneratorHandler) *checkGuard {
+	return &checkGuard{cause: cause, registry: registry, handler: handler}
+}
+
+// update takes the result of a check, nil if it passed.
+func (g *checkGuard) update(err error) {
+	if err != nil {
+		if !g.failing {
+			errorf("%v, not issuing ids until the %s check passes", err, g.cause)
+			g.setStatus(statusWarning)
+			g.failing = true
+		}
+		g.handler.suspend(g.cause, err.Error())
+	} else if g.failing {
+		g.reset(fmt.Sprintf("%s check passed, issuing ids", g.cause))
+	}
+}
+
+// reset issues ids again if the check failed, when it is not run any more.
+func (g *checkGuard) reset(message string) {
+	if !g.failing {
+		return
+	}
+	infof("%s", message)
+	g.handler.resume(g.cause)
+	if !g.handler.Suspended() {
+		g.setStatus(statusAlive)
+	}
+	g.failing = false
+}
+
+func (g *checkGuard) setStatus(status string) {
+	if err := g.registry.SetStatus(status); err != nil {
+		warnf("cannot change status to %s: %v", status, err)
+	}
+}
+
+// monitorClock compares the clock to the registered peers every interval of
+// the sanity check. While it is off by more than the maximum clock skews no
+// ids are issued and the node is WARNING in the registry, both are undone
+// once the clock is back. Unreachable peers are left out, without any the
+// clock is taken as right.
+func monitorClock(registry Registry, handler *IdGeneratorHandler, host string, certs *certReloader, config *configReloader) {
+	guard := newCheckGuard("clock", registry, handler)
+	for {
+		options := config.Config()
+		if options.SanityCheck.Interval <= 0 {
+			guard.reset("clock check disabled, issuing ids again")
+			time.Sleep(time.Minute)
+			continue
+		}
+		time.Sleep(options.SanityCheck.Interval)
+		addrs, err := registry.Peers()
+		if err != nil {
+			warnf("cannot list peers to check the clock: %v", err)
+			continue
+		}
+		addrs = otherPeers(addrs, host, options.Port)
+		peers, _ := queryPeers(addrs, certs, options.SanityCheck)
+		if len(peers) > 0 {
+			debugf("peer clock offsets: %s", describeOffsets(peers))
+		}
+		guard.update(checkClock(peers, options.DatacenterId, options.SanityCheck))
+	}
+}
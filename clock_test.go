@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckGuard(t *testing.T) {
+	registry := newMemoryRegistry("/test")
+	if err := registry.Register(newServersetMember("10.0.0.1", 3456, 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	handler, _ := NewIdGeneratorHandler(0, 1)
+	clock := newCheckGuard("clock", registry, handler)
+	peers := newCheckGuard("peers", registry, handler)
+	alive := func() bool {
+		addrs, _ := registry.Peers()
+		return len(addrs) == 1
+	}
+
+	clock.update(errors.New("clock is 12s ahead"))
+	peers.update(errors.New("Duplicated workerId 0 in datacenter 1, also used by [10.0.0.2:3456]"))
+	if alive() {
+		t.Fatal("ALIVE with the checks failing")
+	}
+	if reason := handler.Unavailable(); !strings.Contains(reason, "12s ahead") || !strings.Contains(reason, "Duplicated workerId") {
+		t.Fatalf("handler unavailable %q, expected both causes", reason)
+	}
+
+	// the status stays WARNING while the other cause holds the ids back
+	peers.update(nil)
+	if alive() {
+		t.Fatal("ALIVE with the clock check failing")
+	}
+	if reason := handler.Unavailable(); strings.Contains(reason, "Duplicated workerId") || !strings.Contains(reason, "12s ahead") {
+		t.Fatalf("handler unavailable %q, expected only the clock", reason)
+	}
+	clock.reset("clock check disabled")
+	if !alive() {
+		t.Fatal("WARNING with all checks passed")
+	}
+	if reason := handler.Unavailable(); reason != "" {
+		t.Fatalf("handler unavailable %q with all checks passed", reason)
+	}
+}
@@ -0,0 +1,162 @@
+// Command idgenerator-cli calls an id generator server on its thrift port or
+// over http, for both IdGenerator and IdGeneratorV2. The generated *-remote
+// tools cannot call IdGeneratorV2, which is only served multiplexed.
+//
+//	idgenerator-cli -h localhost:3456 getId ORDER
+//	idgenerator-cli -u http://localhost:3457/thrift -P json getIds ORDER 10 HEX
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+func Usage() {
+	fmt.Fprintln(os.Stderr, "Usage of", os.Args[0], "[-h host:port] [-u url] [-http] [-P protocol] function [arg1 [arg2...]]:")
+	flag.PrintDefaults()
+	fmt.Fprintln(os.Stderr, "\nFunctions:")
+	fmt.Fprintln(os.Stderr, "  i64 getId(string scope)")
+	fmt.Fprintln(os.Stderr, "  GetIdsResponse getIds(string scope, [i32 count], [NUMERIC|DECIMAL|HEX])")
+	fmt.Fprintln(os.Stderr, "  ServerInfo getInfo()")
+	fmt.Fprintln(os.Stderr, "  i64 getWorkerId()")
+	fmt.Fprintln(os.Stderr, "  i64 getDatacenterId()")
+	fmt.Fprintln(os.Stderr, "  i64 getTimestamp()")
+	fmt.Fprintln(os.Stderr, "  list<string> getScopes()")
+	fmt.Fprintln(os.Stderr)
+	os.Exit(1)
+}
+
+func main() {
+	flag.Usage = Usage
+	host := flag.String("h", "localhost:3456", "host:port of the thrift port, or of the http port with -http")
+	urlString := flag.String("u", "", "url of thrift over http, e.g. http://localhost:3457/thrift")
+	useHttp := flag.Bool("http", false, "call thrift over http at http://host:port/")
+	protocol := flag.String("P", "binary", "protocol (binary, compact, json)")
+	timeout := flag.Duration("timeout", 10*time.Second, "maximum time to connect and for the call")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		Usage()
+	}
+
+	var trans thrift.TTransport
+	var err error
+	if *useHttp && *urlString == "" {
+		*urlString = "http://" + *host + "/"
+	}
+	if *urlString != "" {
+		trans, err = thrift.NewTHttpPostClientWithOptions(*urlString, thrift.THttpClientOptions{Client: &http.Client{Timeout: *timeout}})
+	} else if trans, err = thrift.NewTSocketTimeout(*host, *timeout); err == nil {
+		// the server detects framed and unframed transports
+		trans = thrift.NewTFramedTransport(trans)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error creating transport:", err)
+		os.Exit(1)
+	}
+	var protocolFactory thrift.TProtocolFactory
+	switch *protocol {
+	case "binary":
+		protocolFactory = thrift.NewTBinaryProtocolFactoryDefault()
+	case "compact":
+		protocolFactory = thrift.NewTCompactProtocolFactory()
+	case "json":
+		protocolFactory = thrift.NewTJSONProtocolFactory()
+	default:
+		fmt.Fprintln(os.Stderr, "Invalid protocol:", *protocol)
+		Usage()
+	}
+	if err := trans.Open(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening", *host, err)
+		os.Exit(1)
+	}
+	defer trans.Close()
+	// IdGenerator is also served un-multiplexed, so older servers answer it
+	client := idgenerator.NewIdGeneratorClientFactory(trans, protocolFactory)
+	iprot := protocolFactory.GetProtocol(trans)
+	clientV2 := idgenerator.NewIdGeneratorV2ClientProtocol(trans, iprot, thrift.NewTMultiplexedProtocol(iprot, "IdGeneratorV2"))
+
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "getId":
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "getId requires 1 arg")
+			Usage()
+		}
+		id, err := client.GetId(args[0])
+		check(err)
+		fmt.Println(id)
+	case "getIds":
+		if len(args) < 1 || len(args) > 3 {
+			fmt.Fprintln(os.Stderr, "getIds requires 1-3 args")
+			Usage()
+		}
+		request := idgenerator.NewGetIdsRequest()
+		request.Scope = args[0]
+		if len(args) > 1 {
+			count, err := strconv.ParseInt(args[1], 10, 32)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Invalid count:", args[1])
+				Usage()
+			}
+			request.Count = int32(count)
+		}
+		if len(args) > 2 {
+			if request.Format, err = idgenerator.IdFormatFromString(strings.ToUpper(args[2])); err != nil {
+				fmt.Fprintln(os.Stderr, "Invalid format:", args[2])
+				Usage()
+			}
+		}
+		response, err := clientV2.GetIds(request)
+		check(err)
+		if len(response.FormattedIds) > 0 {
+			fmt.Println(strings.Join(response.FormattedIds, "\n"))
+		} else {
+			for _, id := range response.Ids {
+				fmt.Println(id)
+			}
+		}
+	case "getInfo":
+		info, err := clientV2.GetInfo()
+		check(err)
+		fmt.Println("workerId:", info.WorkerId)
+		fmt.Println("datacenterId:", info.DatacenterId)
+		fmt.Println("timestamp:", info.Timestamp)
+		fmt.Println("scopes:", strings.Join(info.Scopes, ","))
+	case "getWorkerId":
+		workerId, err := client.GetWorkerId()
+		check(err)
+		fmt.Println(workerId)
+	case "getDatacenterId":
+		datacenterId, err := client.GetDatacenterId()
+		check(err)
+		fmt.Println(datacenterId)
+	case "getTimestamp":
+		timestamp, err := client.GetTimestamp()
+		check(err)
+		fmt.Println(timestamp)
+	case "getScopes":
+		scopes, err := client.GetScopes()
+		check(err)
+		for _, scope := range scopes {
+			fmt.Println(scope)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Invalid function", flag.Arg(0))
+		Usage()
+	}
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
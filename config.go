@@ -0,0 +1,367 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+type SanityCheckOptions struct {
+	// how far our clock may be from the median of the peers in our
+	// datacenter and from that of the peers in the others
+	MaxClockSkew        time.Duration `yaml:"maxClockSkew"`
+	MaxCrossDcClockSkew time.Duration `yaml:"maxCrossDcClockSkew"`
+	// timestamps read from every peer to estimate its clock offset
+	ClockSamples int `yaml:"clockSamples"`
+	// how often the clock is compared to the peers while serving
+	Interval time.Duration `yaml:"interval"`
+	// limit of connecting to a peer and of each call
+	PeerTimeout time.Duration `yaml:"peerTimeout"`
+	// fraction of the peers which must answer at startup, all in strict mode
+	Quorum float64 `yaml:"quorum"`
+	Strict bool    `yaml:"strict"`
+}
+
+type LoggingOptions struct {
+	Level string `yaml:"level"`
+	File  string `yaml:"file"`
+}
+
+type Config struct {
+	Port         int                `yaml:"port"`
+	WorkerId     int64              `yaml:"workerId"`
+	AutoWorkerId bool               `yaml:"autoWorkerId"`
+	WorkerLease  time.Duration      `yaml:"workerLease"`
+	DatacenterId int64              `yaml:"datacenterId"`
+	ZkServers    stringList         `yaml:"zkServers"`
+	Registry     string             `yaml:"registry"`
+	BasePath     string             `yaml:"basePath"`
+	SanityCheck  SanityCheckOptions `yaml:"sanityCheck"`
+	Logging      LoggingOptions     `yaml:"logging"`
+	Server       ServerOptions      `yaml:"server"`
+	Shutdown     ShutdownOptions    `yaml:"shutdown"`
+	TLS          TLSOptions         `yaml:"tls"`
+	HTTP         HTTPOptions        `yaml:"http"`
+	Admin        AdminOptions       `yaml:"admin"`
+	Advertise    AdvertiseOptions   `yaml:"advertise"`
+	Coordinator  CoordinatorOptions `yaml:"coordinator"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		WorkerLease: 10 * time.Second,
+		BasePath:    "/service",
+		SanityCheck: SanityCheckOptions{
+			MaxClockSkew:        10 * time.Second,
+			MaxCrossDcClockSkew: time.Minute,
+			ClockSamples:        5,
+			Interval:            30 * time.Second,
+			PeerTimeout:         3 * time.Second,
+			Quorum:              0.5,
+		},
+		Logging: LoggingOptions{Level: "info"},
+		Server: ServerOptions{
+			MaxConnections: 1024,
+			IdleTimeout:    10 * time.Minute,
+			ReadTimeout:    10 * time.Second,
+			MaxFrameSize:   1024 * 1024,
+		},
+		Shutdown:    ShutdownOptions{Grace: 2 * time.Second, DrainTimeout: 10 * time.Second},
+		TLS:         TLSOptions{ReloadInterval: time.Minute},
+		HTTP:        HTTPOptions{Path: "/"},
+		Advertise:   AdvertiseOptions{CIDRs: defaultAdvertiseCIDRs},
+		Coordinator: CoordinatorOptions{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *Config) bindFlags(flags *flag.FlagSet) {
+	flags.IntVar(&c.Port, "p", c.Port, "port to listen to")
+	flags.Int64Var(&c.WorkerId, "w", c.WorkerId, "worker id (0-15)")
+	flags.BoolVar(&c.AutoWorkerId, "auto-worker-id", c.AutoWorkerId, "claim a free worker id of the data center in zookeeper instead of -w")
+	flags.DurationVar(&c.WorkerLease, "worker-lease", c.WorkerLease, "lease of the worker id in zookeeper, a node taking it over after a crash waits this long")
+	flags.Int64Var(&c.DatacenterId, "dc", c.DatacenterId, "data center id (0-7)")
+	flags.Var(&c.ZkServers, "zk", "check and register with zookeepers(`ip:port,ip:port,..`)")
+	flags.StringVar(&c.Registry, "registry", c.Registry, "check and register with `zk://ip:port,.., etcd://ip:port,.., static://ip:port,.., file:///path or memory://` instead of -zk")
+	flags.StringVar(&c.BasePath, "zk-path", c.BasePath, "zookeeper path the serverset is registered under")
+	flags.DurationVar(&c.SanityCheck.MaxClockSkew, "max-clock-skew", c.SanityCheck.MaxClockSkew, "maximum offset of our clock from the median of the peers in our data center")
+	flags.DurationVar(&c.SanityCheck.MaxCrossDcClockSkew, "max-cross-dc-clock-skew", c.SanityCheck.MaxCrossDcClockSkew, "maximum offset of our clock from the median of the peers in other data centers")
+	flags.IntVar(&c.SanityCheck.ClockSamples, "clock-samples", c.SanityCheck.ClockSamples, "timestamps to read from each peer, the half with the longest round trips is discarded")
+	flags.DurationVar(&c.SanityCheck.Interval, "clock-check-interval", c.SanityCheck.Interval, "how often to compare the clock to the peers while serving, ids are refused while it is off by more than -max-clock-skew (0 for only at startup)")
+	flags.DurationVar(&c.SanityCheck.PeerTimeout, "peer-timeout", c.SanityCheck.PeerTimeout, "maximum time to connect to a peer and for each call")
+	flags.Float64Var(&c.SanityCheck.Quorum, "quorum", c.SanityCheck.Quorum, "fraction of the peers which must answer the startup check, unreachable ones are skipped")
+	flags.BoolVar(&c.SanityCheck.Strict, "strict-sanity-check", c.SanityCheck.Strict, "fail the startup check if any peer does not answer")
+	flags.StringVar(&c.Logging.Level, "log-level", c.Logging.Level, "log level (debug, info, warn, error)")
+	flags.StringVar(&c.Logging.File, "log-file", c.Logging.File, "log to this file instead of stdout, reopened on SIGHUP")
+	flags.IntVar(&c.Server.MaxConnections, "max-conns", c.Server.MaxConnections, "maximum concurrent connections, more are closed right away")
+	flags.DurationVar(&c.Server.IdleTimeout, "idle-timeout", c.Server.IdleTimeout, "close connections idle for longer than this (0 for never)")
+	flags.DurationVar(&c.Server.ReadTimeout, "read-timeout", c.Server.ReadTimeout, "maximum time to read one request (0 for no limit)")
+	flags.IntVar(&c.Server.MaxFrameSize, "max-frame-size", c.Server.MaxFrameSize, "maximum request size in bytes")
+	flags.DurationVar(&c.Shutdown.Grace, "shutdown-grace", c.Shutdown.Grace, "wait after deregistering before closing the port")
+	flags.DurationVar(&c.Shutdown.DrainTimeout, "drain-timeout", c.Shutdown.DrainTimeout, "maximum time to finish requests in progress on shutdown")
+	flags.StringVar(&c.TLS.CertFile, "tls-cert", c.TLS.CertFile, "TLS certificate file, enables TLS for server and peer connections")
+	flags.StringVar(&c.TLS.KeyFile, "tls-key", c.TLS.KeyFile, "TLS private key file")
+	flags.StringVar(&c.TLS.CAFile, "tls-ca", c.TLS.CAFile, "CA file to verify peers and client certificates")
+	flags.BoolVar(&c.TLS.VerifyClient, "tls-verify-client", c.TLS.VerifyClient, "require client certificates signed by -tls-ca")
+	flags.Var(&c.TLS.AllowedClients, "tls-allowed-clients", "only accept client certificates with these common names(`name,name,..`)")
+	flags.StringVar(&c.TLS.ServerName, "tls-server-name", c.TLS.ServerName, "expected server name in peer certificates (default peer host)")
+	flags.DurationVar(&c.TLS.ReloadInterval, "tls-reload", c.TLS.ReloadInterval, "interval to check TLS files for changes")
+	flags.IntVar(&c.HTTP.Port, "http-port", c.HTTP.Port, "also serve thrift over http on this port (0 for disabled)")
+	flags.StringVar(&c.HTTP.Path, "http-path", c.HTTP.Path, "url path of thrift over http")
+	flags.Var(&c.HTTP.AllowedOrigins, "http-allowed-origins", "origins browsers may call thrift over http from(`origin,origin,..` or *)")
+	flags.StringVar(&c.Advertise.Host, "advertise-host", c.Advertise.Host, "host or IP to register instead of a local address")
+	flags.StringVar(&c.Advertise.Interface, "advertise-interface", c.Advertise.Interface, "network interface to take the registered address from")
+	flags.Var(&c.Advertise.CIDRs, "advertise-cidrs", "networks to take the registered address from, in order of preference(`cidr,cidr,..`)")
+	flags.Var(&c.Advertise.Endpoints, "additional-endpoints", "endpoints to register besides http and admin, which are added when enabled(`name=port,name=host:port,..`)")
+	flags.IntVar(&c.Admin.Port, "admin-port", c.Admin.Port, "serve /health, /status and /debug/vars on this port (0 for disabled)")
+	flags.BoolVar(&c.Coordinator.Enabled, "coordinator", c.Coordinator.Enabled, "elect a coordinator through the registry which assigns worker ids and checks the clocks of all nodes, on the admin port")
+	flags.DurationVar(&c.Coordinator.Timeout, "coordinator-timeout", c.Coordinator.Timeout, "how long to wait for a worker id from the coordinator")
+}
+
+// envNames maps short flags to readable environment variable names, the
+// others are IDGENERATOR_ followed by the flag name, e.g. IDGENERATOR_LOG_LEVEL.
+var envNames = map[string]string{
+	"p":  "IDGENERATOR_PORT",
+	"w":  "IDGENERATOR_WORKER_ID",
+	"dc": "IDGENERATOR_DATACENTER_ID",
+	"zk": "IDGENERATOR_ZK_SERVERS",
+}
+
+func envName(flagName string) string {
+	if name, found := envNames[flagName]; found {
+		return name
+	}
+	return "IDGENERATOR_" + strings.ToUpper(strings.Replace(flagName, "-", "_", -1))
+}
+
+// loadConfig builds the configuration from the defaults, the config file,
+// IDGENERATOR_* environment variables and the flags set on the command line,
+// each overriding the one before.
+func loadConfig(file string, flags *flag.FlagSet) (*Config, error) {
+	config := defaultConfig()
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.UnmarshalStrict(data, config); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %v", file, err)
+		}
+	}
+	bound := flag.NewFlagSet("config", flag.ContinueOnError)
+	config.bindFlags(bound)
+	var err error
+	bound.VisitAll(func(f *flag.Flag) {
+		if value, found := os.LookupEnv(envName(f.Name)); found && err == nil {
+			if e := f.Value.Set(value); e != nil {
+				err = fmt.Errorf("invalid %s: %v", envName(f.Name), e)
+			}
+		}
+	})
+	flags.Visit(func(f *flag.Flag) {
+		if bound.Lookup(f.Name) != nil && err == nil {
+			err = bound.Set(f.Name, f.Value.String())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return config, config.validate()
+}
+
+func (c *Config) validate() error {
+	if c.Port <= 0 {
+		return errors.New("port is required")
+	}
+	if c.Registry != "" && len(c.ZkServers) > 0 {
+		return errors.New("zookeeper servers and registry cannot both be set")
+	}
+	kind, _, err := c.registry()
+	if err != nil {
+		return err
+	}
+	if c.AutoWorkerId && (kind == "" || kind == "static" || kind == "file") {
+		return errors.New("auto worker id requires a zk, etcd or memory registry")
+	}
+	if c.Coordinator.Enabled && (kind == "" || kind == "static" || kind == "file") {
+		return errors.New("coordinator requires a zk, etcd or memory registry")
+	}
+	if c.Coordinator.Enabled && c.Admin.Port == 0 {
+		return errors.New("coordinator requires the admin port")
+	}
+	if c.Coordinator.Timeout <= 0 {
+		return errors.New("coordinator timeout must be positive")
+	}
+	if c.WorkerLease < 3*time.Millisecond {
+		return errors.New("worker lease must be at least 3ms")
+	}
+	if c.SanityCheck.ClockSamples <= 0 {
+		return errors.New("clock samples must be positive")
+	}
+	if c.SanityCheck.PeerTimeout <= 0 {
+		return errors.New("peer timeout must be positive")
+	}
+	if c.SanityCheck.Quorum < 0 || c.SanityCheck.Quorum > 1 {
+		return errors.New("quorum must be in 0-1")
+	}
+	if c.SanityCheck.Interval < 0 {
+		return errors.New("clock check interval cannot be negative")
+	}
+	if c.Server.MaxConnections <= 0 {
+		return errors.New("max connections must be positive")
+	}
+	if c.Server.MaxFrameSize <= 0 {
+		return errors.New("max frame size must be positive")
+	}
+	if c.HTTP.Port < 0 || c.HTTP.Port > 0 && c.HTTP.Port == c.Port {
+		return errors.New("http port must differ from the thrift port")
+	}
+	if c.Admin.Port < 0 || c.Admin.Port > 0 && (c.Admin.Port == c.Port || c.Admin.Port == c.HTTP.Port) {
+		return errors.New("admin port must differ from the thrift and http ports")
+	}
+	if _, err := parseCIDRs(c.Advertise.CIDRs); err != nil {
+		return err
+	}
+	if _, err := additionalEndpoints(c, "localhost"); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(c.HTTP.Path, "/") {
+		return fmt.Errorf("http path %q must start with /", c.HTTP.Path)
+	}
+	_, err = parseLogLevel(c.Logging.Level)
+	return err
+}
+
+// registry returns the kind of registry, zk, etcd, memory, static or file,
+// and its servers, the peers or the peers file, or an empty kind without one.
+func (c *Config) registry() (string, []string, error) {
+	if len(c.ZkServers) > 0 {
+		return "zk", c.ZkServers, nil
+	}
+	if c.Registry == "" {
+		return "", nil, nil
+	}
+	parts := strings.SplitN(c.Registry, "://", 2)
+	if len(parts) != 2 {
+		return "", nil, fmt.Errorf("registry %q must be a url like zk://ip:port,..", c.Registry)
+	}
+	switch parts[0] {
+	case "memory":
+		return parts[0], nil, nil
+	case "file":
+		if parts[1] == "" {
+			return "", nil, fmt.Errorf("registry %q has no file", c.Registry)
+		}
+		return parts[0], []string{parts[1]}, nil
+	case "zk", "etcd", "static":
+		servers := splitList(strings.TrimSuffix(parts[1], "/"))
+		if len(servers) == 0 {
+			return "", nil, fmt.Errorf("registry %q has no servers", c.Registry)
+		}
+		return parts[0], servers, nil
+	}
+	return "", nil, fmt.Errorf("unknown registry %q, must be zk://, etcd://, static://, file:// or memory://", c.Registry)
+}
+
+// restartRequired lists the settings which differ between c and next but
+// cannot be changed while serving.
+func (c *Config) restartRequired(next *Config) []string {
+	var changed []string
+	check := func(name string, old, new interface{}) {
+		if !reflect.DeepEqual(old, new) {
+			changed = append(changed, fmt.Sprintf("%s (%v -> %v)", name, old, new))
+		}
+	}
+	check("port", c.Port, next.Port)
+	check("workerId", c.WorkerId, next.WorkerId)
+	check("autoWorkerId", c.AutoWorkerId, next.AutoWorkerId)
+	check("workerLease", c.WorkerLease, next.WorkerLease)
+	check("datacenterId", c.DatacenterId, next.DatacenterId)
+	check("zkServers", c.ZkServers, next.ZkServers)
+	check("registry", c.Registry, next.Registry)
+	check("basePath", c.BasePath, next.BasePath)
+	check("tls enabled", c.TLS.Enabled(), next.TLS.Enabled())
+	check("http", c.HTTP, next.HTTP)
+	check("admin", c.Admin, next.Admin)
+	check("advertise", c.Advertise, next.Advertise)
+	check("coordinator", c.Coordinator, next.Coordinator)
+	return changed
+}
+
+// configReloader applies the settings that are safe to change while serving
+// when the process receives SIGHUP.
+type configReloader struct {
+	file   string
+	flags  *flag.FlagSet
+	config *Config
+	server *IdGeneratorServer
+	certs  *certReloader
+	mux    sync.Mutex
+}
+
+func newConfigReloader(file string, flags *flag.FlagSet, config *Config, server *IdGeneratorServer, certs *certReloader) *configReloader {
+	return &configReloader{file: file, flags: flags, config: config, server: server, certs: certs}
+}
+
+func (p *configReloader) Config() *Config {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.config
+}
+
+func (p *configReloader) watch() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		if err := p.reload(); err != nil {
+			errorf("config reload rejected: %v", err)
+		} else {
+			infof("config reloaded")
+		}
+	}
+}
+
+func (p *configReloader) reload() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	next, err := loadConfig(p.file, p.flags)
+	if err != nil {
+		return err
+	}
+	if changed := p.config.restartRequired(next); len(changed) > 0 {
+		return fmt.Errorf("restart required to change %s", strings.Join(changed, ", "))
+	}
+	if p.certs != nil {
+		if err := p.certs.update(next.TLS); err != nil {
+			return err
+		}
+	}
+	if err := setLogFile(next.Logging.File); err != nil {
+		return fmt.Errorf("cannot open log file: %v", err)
+	}
+	setLogLevel(next.Logging.Level)
+	p.server.SetOptions(next.Server)
+	p.config = next
+	return nil
+}
+
+// stringList is a comma separated flag and a yaml sequence.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = splitList(value)
+	return nil
+}
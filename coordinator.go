@@ -0,0 +1,606 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type CoordinatorOptions struct {
+	// elect a coordinator which assigns the worker ids and checks the clocks
+	// of all members, it answers on the admin port
+	Enabled bool `yaml:"enabled"`
+	// how long a joining node waits for a worker id from the coordinator
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// how often the coordinator node is read, the others take over within this
+// once it is gone
+var coordinatorPollInterval = time.Second
+
+var errNotCoordinator = errors.New("not the coordinator")
+
+// coordinatorError is a refusal of the coordinator, asking again does not
+// change it.
+type coordinatorError struct {
+	message string
+}
+
+func (e *coordinatorError) Error() string {
+	return e.message
+}
+
+// coordinatorRecord is the ephemeral node <basePath>/idgenerators/coordinator
+// of the elected node, it goes away with the session of the coordinator.
+type coordinatorRecord struct {
+	// the serverset endpoint of the coordinator
+	Owner string `json:"owner"`
+	// host:port of its admin server, where it answers
+	Admin string `json:"admin"`
+}
+
+// idLayout is how ids are composed. It is only compared when joining, a
+// member with another layout refuses to start rather than adopting it.
+type idLayout struct {
+	Epoch            int64 `json:"epoch"`
+	DatacenterIdBits uint  `json:"datacenterIdBits"`
+	WorkerIdBits     uint  `json:"workerIdBits"`
+	SequenceBits     uint  `json:"sequenceBits"`
+}
+
+func currentLayout() idLayout {
+	return idLayout{Epoch: epoch, DatacenterIdBits: datacenterIdBits, WorkerIdBits: workerIdBits, SequenceBits: sequenceBits}
+}
+
+// clusterMember is what the coordinator learnt about a member by its last
+// scan.
+type clusterMember struct {
+	Addr         string `json:"addr"`
+	DatacenterId int64  `json:"datacenterId"`
+	WorkerId     int64  `json:"workerId"`
+	// how far the clock of the member is ahead of the coordinator's and the
+	// round trip it was measured with, in nanoseconds
+	Offset time.Duration `json:"offset"`
+	Rtt    time.Duration `json:"rtt"`
+	// why the member should not issue ids, empty if it may
+	Problem string `json:"problem,omitempty"`
+}
+
+// clusterState is what the coordinator serves on /coordinator/state.
+type clusterState struct {
+	Coordinator string   `json:"coordinator"`
+	Timestamp   int64    `json:"timestamp"`
+	Layout      idLayout `json:"layout"`
+	// when the members were scanned, 0 before the first scan
+	Checked     int64           `json:"checked"`
+	Members     []clusterMember `json:"members"`
+	Unreachable []string        `json:"unreachable,omitempty"`
+}
+
+// assignRequest asks the coordinator for a worker id, any free one if
+// WorkerId is negative.
+type assignRequest struct {
+	Owner        string `json:"owner"`
+	DatacenterId int64  `json:"datacenterId"`
+	WorkerId     int64  `json:"workerId"`
+}
+
+type assignment struct {
+	WorkerId int64    `json:"workerId"`
+	Layout   idLayout `json:"layout"`
+}
+
+// a worker id assigned to a node which has not registered yet
+type reservation struct {
+	owner string
+	until time.Time
+}
+
+// coordinator elects one node through the registry, instead of every node
+// scanning the peers. The elected node assigns the worker ids, checks the id
+// layout and scans the clocks of all members, the others ask it. Nothing
+// is kept but in the registry, so another node takes over when it dies.
+type coordinator struct {
+	registry Registry
+	basePath string
+	self     coordinatorRecord
+	host     string
+	port     int
+	timeout  time.Duration
+	certs    *certReloader
+	client   *http.Client
+	// our ids, the worker id once assigned
+	datacenterId int64
+	workerId     int64
+	// the coordinator last read
+	record   coordinatorRecord
+	leading  bool
+	state    clusterState
+	reserved map[string]reservation
+	mux      sync.Mutex
+}
+
+func newCoordinator(registry Registry, config *Config, host string, certs *certReloader) *coordinator {
+	client := &http.Client{Timeout: config.SanityCheck.PeerTimeout}
+	if certs != nil {
+		// the admin ports are served with TLS too, the config is taken for
+		// every dial to follow reloaded certificates
+		client.Transport = &http.Transport{
+			DialTLSContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+				dialer := &tls.Dialer{Config: certs.ClientConfig()}
+				return dialer.DialContext(ctx, network, addr)
+			},
+		}
+	}
+	return &coordinator{
+		registry: registry,
+		basePath: config.BasePath,
+		self: coordinatorRecord{
+			Owner: net.JoinHostPort(host, strconv.Itoa(config.Port)),
+			Admin: net.JoinHostPort(host, strconv.Itoa(config.Admin.Port)),
+		},
+		host:         host,
+		port:         config.Port,
+		timeout:      config.Coordinator.Timeout,
+		certs:        certs,
+		client:       client,
+		datacenterId: config.DatacenterId,
+		workerId:     -1,
+		reserved:     make(map[string]reservation),
+	}
+}
+
+func (c *coordinator) path() string {
+	return c.basePath + "/idgenerators/coordinator"
+}
+
+// elect reads the coordinator, becoming it if there is none, and tells
+// whether it is us.
+func (c *coordinator) elect() (coordinatorRecord, bool, error) {
+	var record coordinatorRecord
+	for {
+		data, _, err := c.registry.get(c.path())
+		if err == errNoNode {
+			data, _ = json.Marshal(c.self)
+			err = c.registry.createEphemeral(c.path(), data)
+			if err == errNodeExists {
+				continue
+			}
+		}
+		if err != nil {
+			return record, false, fmt.Errorf("cannot read coordinator %s: %v", c.path(), err)
+		}
+		if err := json.Unmarshal(data, &record); err != nil {
+			return record, false, fmt.Errorf("invalid coordinator %s: %v", c.path(), err)
+		}
+		break
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	leading := record == c.self
+	if leading && !c.leading {
+		infof("elected coordinator")
+		c.state = clusterState{}
+	} else if !leading && c.leading {
+		warnf("no longer the coordinator, %s is", record.Owner)
+	} else if !leading && record != c.record {
+		infof("coordinator is %s", record.Owner)
+	}
+	c.record = record
+	c.leading = leading
+	return record, leading, nil
+}
+
+// Coordinator returns the endpoint of the coordinator last read.
+func (c *coordinator) Coordinator() string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.record.Owner
+}
+
+// join returns the worker id assigned to a joining node. Unless it was
+// elected itself it also returns the clocks of the coordinator and the other
+// members, by how far they are ahead of ours, for the sanity check. Elected,
+// it checks its clock against the members before assigning itself a worker
+// id. It tries again while the coordinator is starting or failing over.
+func (c *coordinator) join(config *Config) (int64, []*peerClock, error) {
+	request := assignRequest{Owner: c.self.Owner, DatacenterId: config.DatacenterId, WorkerId: config.WorkerId}
+	if config.AutoWorkerId {
+		request.WorkerId = -1
+	}
+	deadline := time.Now().Add(c.timeout)
+	for {
+		record, leading, err := c.elect()
+		if err == nil && leading {
+			// no coordinator compares our clock to the members, it is
+			// checked before a worker id is assigned as the others do
+			var peers []*peerClock
+			if peers, err = c.queryMembers(config.SanityCheck); err == nil {
+				debugf("member clock offsets: %s", describeOffsets(peers))
+				if err := checkClock(peers, config.DatacenterId, config.SanityCheck); err != nil {
+					return -1, nil, fmt.Errorf("Clock sanity check failed: %v", err)
+				}
+				assigned, err := c.assign(request)
+				if err != nil {
+					return -1, nil, err
+				}
+				c.setWorkerId(assigned.WorkerId)
+				return assigned.WorkerId, nil, nil
+			}
+		}
+		if err == nil {
+			var peers []*peerClock
+			var assigned assignment
+			if peers, _, err = c.sample(record, config.SanityCheck); err == nil {
+				if err = c.call(record, "/coordinator/assign", request, &assigned); err == nil {
+					if assigned.Layout != currentLayout() {
+						return -1, nil, fmt.Errorf("id layout %+v of coordinator %s differs from ours %+v", assigned.Layout, record.Owner, currentLayout())
+					}
+					infof("coordinator %s assigned worker id %d", record.Owner, assigned.WorkerId)
+					c.setWorkerId(assigned.WorkerId)
+					return assigned.WorkerId, peers, nil
+				}
+			}
+		}
+		if _, refused := err.(*coordinatorError); refused {
+			return -1, nil, fmt.Errorf("coordinator refused worker id: %v", err)
+		}
+		if time.Now().After(deadline) {
+			return -1, nil, fmt.Errorf("no worker id from the coordinator within %v: %v", c.timeout, err)
+		}
+		warnf("waiting for the coordinator: %v", err)
+		time.Sleep(coordinatorPollInterval)
+	}
+}
+
+// queryMembers samples the clocks of the other members.
+func (c *coordinator) queryMembers(options SanityCheckOptions) ([]*peerClock, error) {
+	addrs, err := c.registry.Peers()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list members: %v", err)
+	}
+	peers, unreachable := queryPeers(otherPeers(addrs, c.host, c.port), c.certs, options)
+	if len(unreachable) > 0 {
+		warnf("skipped unreachable members %v, %d of %d answered", unreachable, len(peers), len(peers)+len(unreachable))
+	}
+	return peers, nil
+}
+
+func (c *coordinator) setWorkerId(workerId int64) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.workerId = workerId
+}
+
+// assign picks the worker id of a joining node, the one asked for unless a
+// member, another joining node or the registry has it, or the lowest one
+// free. It is reserved for the node while it claims it. The registry is read
+// as the members are only known after a scan, and nodes may hold a worker id
+// without being members, e.g. while their lease runs out.
+func (c *coordinator) assign(request assignRequest) (assignment, error) {
+	if request.DatacenterId < 0 || request.DatacenterId > maxDatacenterId {
+		return assignment{}, &coordinatorError{fmt.Sprintf("datacenter id must be in 0-%d", maxDatacenterId)}
+	}
+	if request.WorkerId > maxWorkerId {
+		return assignment{}, &coordinatorError{fmt.Sprintf("worker id must be in 0-%d", maxWorkerId)}
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if !c.leading {
+		return assignment{}, errNotCoordinator
+	}
+	now := time.Now()
+	for key, r := range c.reserved {
+		if now.After(r.until) {
+			delete(c.reserved, key)
+		}
+	}
+	key := func(id int64) string {
+		return fmt.Sprintf("%d/%d", request.DatacenterId, id)
+	}
+	holder := func(id int64) string {
+		if r, found := c.reserved[key(id)]; found && r.owner != request.Owner {
+			return r.owner
+		}
+		for _, member := range c.state.Members {
+			if member.DatacenterId == request.DatacenterId && member.WorkerId == id && member.Addr != request.Owner {
+				return member.Addr
+			}
+		}
+		return ""
+	}
+	workerId := request.WorkerId
+	if workerId >= 0 {
+		owner := holder(workerId)
+		if owner == "" {
+			var err error
+			if owner, err = c.registryHolder(request.DatacenterId, workerId, request.Owner); err != nil {
+				return assignment{}, err
+			}
+		}
+		if owner != "" {
+			return assignment{}, &coordinatorError{fmt.Sprintf("worker id %d of datacenter %d is used by %s", workerId, request.DatacenterId, owner)}
+		}
+	} else {
+		for id := int64(0); id <= maxWorkerId && workerId < 0; id++ {
+			if holder(id) != "" {
+				continue
+			}
+			owner, err := c.registryHolder(request.DatacenterId, id, request.Owner)
+			if err != nil {
+				return assignment{}, err
+			}
+			if owner == "" {
+				workerId = id
+			}
+		}
+		if workerId < 0 {
+			return assignment{}, &coordinatorError{fmt.Sprintf("all %d worker ids of datacenter %d are taken", maxWorkerId+1, request.DatacenterId)}
+		}
+	}
+	c.reserved[key(workerId)] = reservation{owner: request.Owner, until: now.Add(c.timeout)}
+	infof("assigned worker id %d of datacenter %d to %s", workerId, request.DatacenterId, request.Owner)
+	return assignment{WorkerId: workerId, Layout: currentLayout()}, nil
+}
+
+// registryHolder returns who holds a worker id by the registry, through its
+// worker id node or a lease not expired, or "" if nobody but owner does.
+func (c *coordinator) registryHolder(datacenterId int64, workerId int64, owner string) (string, error) {
+	id := strconv.FormatInt(workerId, 10)
+	data, _, err := c.registry.get(workersPath(c.basePath, datacenterId) + "/" + id)
+	if err == nil && string(data) != owner {
+		return string(data), nil
+	} else if err != nil && err != errNoNode {
+		return "", fmt.Errorf("cannot read worker id %d: %v", workerId, err)
+	}
+	data, _, err = c.registry.get(leasesPath(c.basePath, datacenterId) + "/" + id)
+	if err == errNoNode {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("cannot read lease of worker id %d: %v", workerId, err)
+	}
+	var lease workerLease
+	if json.Unmarshal(data, &lease) == nil && lease.Owner != owner && lease.LeaseExpiry > getTimestamp() {
+		return lease.Owner, nil
+	}
+	return "", nil
+}
+
+// run keeps electing the coordinator and checks the clock every interval of
+// the sanity check, like monitorClock. The coordinator scans all members and
+// checks its clock against them, the others check theirs against the state
+// of the coordinator and stop issuing ids while it reports a problem with
+// them.
+func (c *coordinator) run(handler *IdGeneratorHandler, config *configReloader) {
+	guard := newCheckGuard("clock", c.registry, handler)
+	var checked time.Time
+	wasLeading := false
+	for ; ; time.Sleep(coordinatorPollInterval) {
+		options := config.Config()
+		record, leading, err := c.elect()
+		if err != nil {
+			warnf("%v", err)
+			continue
+		}
+		if leading != wasLeading {
+			checked = time.Time{}
+			wasLeading = leading
+		}
+		if options.SanityCheck.Interval <= 0 {
+			guard.reset("clock check disabled, issuing ids again")
+			continue
+		}
+		if time.Since(checked) < options.SanityCheck.Interval {
+			continue
+		}
+		checked = time.Now()
+		if leading {
+			addrs, err := c.registry.Peers()
+			if err != nil {
+				warnf("cannot list members to check the clocks: %v", err)
+				continue
+			}
+			guard.update(c.scan(addrs, options.SanityCheck))
+		} else {
+			peers, problem, err := c.sample(record, options.SanityCheck)
+			if err != nil {
+				warnf("cannot read the state of coordinator %s: %v", record.Owner, err)
+				continue
+			}
+			debugf("clock offsets by the coordinator: %s", describeOffsets(peers))
+			if problem != "" {
+				guard.update(fmt.Errorf("coordinator %s reports: %s", record.Owner, problem))
+			} else {
+				guard.update(checkClock(peers, c.datacenterId, options.SanityCheck))
+			}
+		}
+	}
+}
+
+// scan queries the members at addrs, records how far their clocks are ahead
+// of ours and checks each against the others, and returns the check of our
+// own clock.
+func (c *coordinator) scan(addrs []string, options SanityCheckOptions) error {
+	peers, unreachable := queryPeers(otherPeers(addrs, c.host, c.port), c.certs, options)
+	if len(peers) > 0 {
+		debugf("member clock offsets: %s", describeOffsets(peers))
+	}
+	self := &peerClock{addr: c.self.Owner, datacenterId: c.datacenterId, workerId: c.workerId}
+	all := append([]*peerClock{self}, peers...)
+	members := make([]clusterMember, len(all))
+	var own error
+	for i, member := range all {
+		var err error
+		var others []*peerClock
+		for j, other := range all {
+			if j == i {
+				continue
+			}
+			if other.datacenterId == member.datacenterId && other.workerId == member.workerId && err == nil {
+				err = fmt.Errorf("Duplicated workerId %d in datacenter %d, also used by %s", member.workerId, member.datacenterId, other.addr)
+			}
+			others = append(others, &peerClock{
+				addr:         other.addr,
+				datacenterId: other.datacenterId,
+				workerId:     other.workerId,
+				offset:       other.offset - member.offset,
+				rtt:          other.rtt,
+			})
+		}
+		if err == nil {
+			err = checkClock(others, member.datacenterId, options)
+		}
+		members[i] = clusterMember{
+			Addr:         member.addr,
+			DatacenterId: member.datacenterId,
+			WorkerId:     member.workerId,
+			Offset:       member.offset,
+			Rtt:          member.rtt,
+		}
+		if err != nil {
+			members[i].Problem = err.Error()
+		}
+		if i == 0 {
+			own = err
+		} else if err != nil {
+			warnf("member %s: %v", member.addr, err)
+		}
+	}
+	c.mux.Lock()
+	c.state.Members = members
+	c.state.Unreachable = unreachable
+	c.state.Checked = getTimestamp()
+	c.mux.Unlock()
+	return own
+}
+
+// sample reads the state of the coordinator, once per clock sample, and
+// returns the clocks of the coordinator and of the members but us, by how
+// far they are ahead of ours, and the problem it found with us.
+func (c *coordinator) sample(record coordinatorRecord, options SanityCheckOptions) ([]*peerClock, string, error) {
+	coordinator := &peerClock{addr: record.Owner}
+	var state clusterState
+	for i := 0; i < options.ClockSamples; i++ {
+		start := time.Now()
+		if err := c.call(record, "/coordinator/state", nil, &state); err != nil {
+			return nil, "", err
+		}
+		coordinator.samples = append(coordinator.samples, newClockSample(start, time.Now(), state.Timestamp))
+	}
+	coordinator.estimate()
+	var peers []*peerClock
+	var problem string
+	for _, member := range state.Members {
+		if member.Addr == c.self.Owner {
+			problem = member.Problem
+			continue
+		}
+		if member.Addr == record.Owner {
+			coordinator.datacenterId = member.DatacenterId
+			coordinator.workerId = member.WorkerId
+			peers = append(peers, coordinator)
+			continue
+		}
+		peers = append(peers, &peerClock{
+			addr:         member.Addr,
+			datacenterId: member.DatacenterId,
+			workerId:     member.WorkerId,
+			offset:       coordinator.offset + member.Offset,
+			rtt:          member.Rtt,
+		})
+	}
+	return peers, problem, nil
+}
+
+// snapshot returns the cluster state, with only us as member before the
+// first scan.
+func (c *coordinator) snapshot() (clusterState, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if !c.leading {
+		return clusterState{}, errNotCoordinator
+	}
+	state := c.state
+	state.Coordinator = c.self.Owner
+	state.Layout = currentLayout()
+	if state.Checked == 0 {
+		state.Members = []clusterMember{{Addr: c.self.Owner, DatacenterId: c.datacenterId, WorkerId: c.workerId}}
+	}
+	state.Timestamp = getTimestamp()
+	return state, nil
+}
+
+// call sends body to the coordinator, or reads path if it is nil, and decodes
+// the answer into result.
+func (c *coordinator) call(record coordinatorRecord, path string, body interface{}, result interface{}) error {
+	scheme := "http://"
+	if c.certs != nil {
+		scheme = "https://"
+	}
+	url := scheme + record.Admin + path
+	var response *http.Response
+	var err error
+	if body == nil {
+		response, err = c.client.Get(url)
+	} else {
+		data, _ := json.Marshal(body)
+		response, err = c.client.Post(url, "application/json", bytes.NewReader(data))
+	}
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(response.Body)
+		if response.StatusCode == http.StatusConflict {
+			return &coordinatorError{strings.TrimSpace(string(message))}
+		}
+		return fmt.Errorf("coordinator %s answered %s: %s", record.Owner, response.Status, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// handle serves the cluster state on GET /coordinator/state and assigns
+// worker ids on POST /coordinator/assign. Nodes not elected answer 503.
+func (c *coordinator) handle(mux *http.ServeMux) {
+	mux.HandleFunc("/coordinator/state", func(w http.ResponseWriter, r *http.Request) {
+		state, err := c.snapshot()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(state)
+	})
+	mux.HandleFunc("/coordinator/assign", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST an assign request", http.StatusMethodNotAllowed)
+			return
+		}
+		var request assignRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		assigned, err := c.assign(request)
+		if _, refused := err.(*coordinatorError); refused {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(assigned)
+	})
+}
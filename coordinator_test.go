@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+func TestCoordinatorAssign(t *testing.T) {
+	config := testConfig(3456)
+	registry := newMemoryRegistry(config.BasePath)
+	c := newCoordinator(registry, config, "10.0.0.1", nil)
+	if _, leading, err := c.elect(); err != nil || !leading {
+		t.Fatalf("not elected: %v", err)
+	}
+	// before the first scan, worker id 0 is held by its node and 1 by a lease
+	if _, err := registry.join().ClaimWorkerId(1, 0, true, "10.0.0.2:3456", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.join().ClaimWorkerId(1, 1, false, "10.0.0.3:3456", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	for id, owner := range []string{"10.0.0.2:3456", "10.0.0.3:3456"} {
+		_, err := c.assign(assignRequest{Owner: "10.0.0.4:3456", DatacenterId: 1, WorkerId: int64(id)})
+		if _, refused := err.(*coordinatorError); !refused || !strings.Contains(err.Error(), "used by "+owner) {
+			t.Fatalf("assigned worker id %d held by %s, error %v", id, owner, err)
+		}
+	}
+	assigned, err := c.assign(assignRequest{Owner: "10.0.0.4:3456", DatacenterId: 1, WorkerId: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assigned.WorkerId != 2 {
+		t.Fatalf("assigned worker id %d, expected 2", assigned.WorkerId)
+	}
+	// the holder of a lease gets its worker id back
+	if assigned, err := c.assign(assignRequest{Owner: "10.0.0.3:3456", DatacenterId: 1, WorkerId: 1}); err != nil || assigned.WorkerId != 1 {
+		t.Fatalf("worker id 1 not assigned to the holder of its lease: %v", err)
+	}
+}
+
+func TestCoordinatorSampleProblem(t *testing.T) {
+	config := testConfig(3456)
+	registry := newMemoryRegistry(config.BasePath)
+	leader := newCoordinator(registry, config, "10.0.0.1", nil)
+	record, leading, err := leader.elect()
+	if err != nil || !leading {
+		t.Fatalf("not elected: %v", err)
+	}
+	mux := http.NewServeMux()
+	leader.handle(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	record.Admin = strings.TrimPrefix(server.URL, "http://")
+
+	member := newCoordinator(registry.join(), testConfig(3457), "10.0.0.1", nil)
+	leader.mux.Lock()
+	leader.state = clusterState{Checked: getTimestamp(), Members: []clusterMember{
+		{Addr: "10.0.0.1:3456", DatacenterId: 1, WorkerId: 0},
+		{Addr: "10.0.0.1:3457", DatacenterId: 1, WorkerId: 1, Problem: "clock is 12s ahead"},
+	}}
+	leader.mux.Unlock()
+	peers, problem, err := member.sample(record, config.SanityCheck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problem != "clock is 12s ahead" {
+		t.Fatalf("problem %q, expected the one reported for us", problem)
+	}
+	if len(peers) != 1 || peers[0].addr != "10.0.0.1:3456" {
+		t.Fatalf("peers %v, expected only the coordinator", describeOffsets(peers))
+	}
+}
+
+// skewedPeer tells a clock ahead of ours by offset.
+type skewedPeer struct {
+	*IdGeneratorHandler
+	offset time.Duration
+}
+
+func (p skewedPeer) GetInfo() (*idgenerator.ServerInfo, error) {
+	info, err := p.IdGeneratorHandler.GetInfo()
+	if err == nil {
+		info.Timestamp += int64(p.offset / time.Millisecond)
+	}
+	return info, err
+}
+
+// registerSkewedPeer serves a member with its clock ahead by offset and
+// registers it.
+func registerSkewedPeer(t *testing.T, registry *memoryRegistry, offset time.Duration) {
+	transport, err := thrift.NewTServerSocket("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	handler, _ := NewIdGeneratorHandler(5, 1)
+	processor := thrift.NewTMultiplexedProcessor()
+	processor.RegisterProcessor("IdGeneratorV2", idgenerator.NewIdGeneratorV2Processor(skewedPeer{handler, offset}))
+	server := thrift.NewTSimpleServer4(processor, transport, thrift.NewTFramedTransportFactory(thrift.NewTTransportFactory()), thrift.NewTBinaryProtocolFactoryDefault())
+	go server.Serve()
+	t.Cleanup(func() { server.Stop() })
+	host, port, _ := net.SplitHostPort(transport.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	if err := registry.Register(newServersetMember(host, portNumber, 1, 5)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCoordinatorJoinChecksClock checks the clock of a node elected while
+// joining, which no coordinator compares to the members.
+func TestCoordinatorJoinChecksClock(t *testing.T) {
+	config := testConfig(3456)
+	registry := newMemoryRegistry(config.BasePath)
+	peer := registry.join()
+	registerSkewedPeer(t, peer, time.Hour)
+
+	c := newCoordinator(registry, config, "10.0.0.1", nil)
+	if _, _, err := c.join(config); err == nil || !strings.Contains(err.Error(), "Clock sanity check failed") {
+		t.Fatalf("joined with the clock an hour behind the members, error %v", err)
+	}
+	c.mux.Lock()
+	reserved := len(c.reserved)
+	c.mux.Unlock()
+	if reserved != 0 {
+		t.Fatal("worker id assigned before the clock was checked")
+	}
+
+	peer.Deregister()
+	registerSkewedPeer(t, peer, 0)
+	if workerId, _, err := c.join(config); err != nil || workerId != 0 {
+		t.Fatalf("joined with worker id %d: %v", workerId, err)
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+var etcdSessionTTL = 10 * time.Second
+var etcdTimeout = 5 * time.Second
+
+// etcdRegistry keeps the members of the cluster and the worker id claims in
+// etcd under the zookeeper paths. Members and claims are attached to the
+// lease of one session, so they go away together when the process dies. When
+// the lease expires they are created again with a new one, like the zookeeper
+// registry does with the next session.
+type etcdRegistry struct {
+	client   *clientv3.Client
+	session  *concurrency.Session
+	basePath string
+	// the registration, nil when not registered
+	registration *serversetMember
+	// the member key, empty while not in the serverset
+	memberKey string
+	claims    []*workerClaim
+	// the lease expired and the registration is not back yet
+	expired bool
+	closed  chan bool
+	mux     sync.Mutex
+}
+
+func newEtcdRegistry(endpoints []string, basePath string) (*etcdRegistry, error) {
+	client, err := clientv3.New(clientv3.Config{Endpoints: endpoints, DialTimeout: etcdTimeout})
+	if err != nil {
+		return nil, err
+	}
+	session, err := newEtcdSession(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("cannot connect to etcd servers %v: %v", endpoints, err)
+	}
+	r := &etcdRegistry{client: client, session: session, basePath: basePath, closed: make(chan bool)}
+	go r.watchSession()
+	return r, nil
+}
+
+// newEtcdSession grants a lease, which the session keeps alive for as long as
+// its context lives, so only granting it has a timeout.
+func newEtcdSession(client *clientv3.Client) (*concurrency.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	lease, err := client.Grant(ctx, int64(etcdSessionTTL/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return concurrency.NewSession(client, concurrency.WithLease(lease.ID))
+}
+
+// watchSession claims the worker ids and registers the node again with a new
+// lease when the lease of the session expired.
+func (r *etcdRegistry) watchSession() {
+	for {
+		r.mux.Lock()
+		session := r.session
+		r.mux.Unlock()
+		select {
+		case <-session.Done():
+		case <-r.closed:
+			return
+		}
+		// Close ends the session too, after closed
+		select {
+		case <-r.closed:
+			return
+		default:
+		}
+		errorf("etcd lease expired, registration and worker id claims are lost until the next lease")
+		r.mux.Lock()
+		r.expired = true
+		r.memberKey = ""
+		claims := r.claims
+		r.mux.Unlock()
+		for _, claim := range claims {
+			claim.sessionLost()
+		}
+		for {
+			err := r.restore()
+			if err == nil {
+				break
+			}
+			warnf("%v, trying again", err)
+			select {
+			case <-time.After(time.Second):
+			case <-r.closed:
+				return
+			}
+		}
+	}
+}
+
+// restore takes a new lease unless the one taken by a previous try is still
+// alive, claims the worker ids again and then registers the node.
+func (r *etcdRegistry) restore() error {
+	r.mux.Lock()
+	session := r.session
+	claims := r.claims
+	r.mux.Unlock()
+	select {
+	case <-session.Done():
+		session, err := newEtcdSession(r.client)
+		if err != nil {
+			return fmt.Errorf("cannot get a new etcd lease: %v", err)
+		}
+		r.mux.Lock()
+		r.session = session
+		r.mux.Unlock()
+	default:
+	}
+	lost := false
+	for _, claim := range claims {
+		if err := claim.reclaim(); err != nil {
+			return err
+		}
+		select {
+		case <-claim.Lost():
+			lost = true
+		default:
+		}
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.registration != nil && !lost {
+		if err := r.putMember(); err != nil {
+			return fmt.Errorf("cannot register again: %v", err)
+		}
+		infof("registered again as %s", r.memberKey)
+	}
+	r.expired = false
+	return nil
+}
+
+// lease returns the lease of the current session.
+func (r *etcdRegistry) lease() clientv3.LeaseID {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.session.Lease()
+}
+
+func (r *etcdRegistry) membersPrefix() string {
+	return r.basePath + "/idgenerators/member_"
+}
+
+func (r *etcdRegistry) Peers() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	resp, err := r.client.Get(ctx, r.membersPrefix(), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	addrs := []string{}
+	for _, kv := range resp.Kvs {
+		var member serversetMember
+		if err := json.Unmarshal(kv.Value, &member); err != nil {
+			warnf("invalid member %s: %v", kv.Key, err)
+			continue
+		}
+		if member.Status != statusAlive {
+			continue
+		}
+		addrs = append(addrs, member.Addr())
+	}
+	return addrs, nil
+}
+
+func (r *etcdRegistry) Register(member serversetMember) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	member.Status = statusAlive
+	r.registration = &member
+	if err := r.putMember(); err != nil {
+		r.registration = nil
+		return err
+	}
+	return nil
+}
+
+// putMember writes the registration under the key of the current lease, the
+// lock must be held.
+func (r *etcdRegistry) putMember() error {
+	data, _ := json.Marshal(r.registration)
+	key := fmt.Sprintf("%s%016x", r.membersPrefix(), int64(r.session.Lease()))
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	if _, err := r.client.Put(ctx, key, string(data), clientv3.WithLease(r.session.Lease())); err != nil {
+		return err
+	}
+	r.memberKey = key
+	return nil
+}
+
+func (r *etcdRegistry) SetStatus(status string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.registration == nil {
+		return nil
+	}
+	r.registration.Status = status
+	if r.memberKey == "" {
+		return nil
+	}
+	return r.putMember()
+}
+
+func (r *etcdRegistry) Registered() bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.registration != nil && !r.expired
+}
+
+func (r *etcdRegistry) Deregister() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.registration = nil
+	if r.memberKey == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	if _, err := r.client.Delete(ctx, r.memberKey); err != nil {
+		return err
+	}
+	r.memberKey = ""
+	return nil
+}
+
+func (r *etcdRegistry) Watch() (<-chan []string, error) {
+	ctx, cancel := context.WithCancel(clientv3.WithRequireLeader(context.Background()))
+	changes := r.client.Watch(ctx, r.membersPrefix(), clientv3.WithPrefix())
+	addrs, err := r.Peers()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	peers := make(chan []string, 1)
+	peers <- addrs
+	go func() {
+		defer close(peers)
+		defer cancel()
+		for {
+			select {
+			case _, ok := <-changes:
+				if !ok {
+					warnf("etcd watch of %s ended", r.membersPrefix())
+					return
+				}
+			case <-r.closed:
+				return
+			}
+			addrs, err := r.Peers()
+			if err != nil {
+				warnf("cannot list peers in etcd: %v", err)
+				continue
+			}
+			select {
+			case <-peers:
+			default:
+			}
+			peers <- addrs
+		}
+	}()
+	return peers, nil
+}
+
+func (r *etcdRegistry) ClaimWorkerId(datacenterId int64, workerId int64, auto bool, owner string, lease time.Duration) (*workerClaim, error) {
+	claim, err := takeWorkerId(r, r.basePath, datacenterId, workerId, auto, owner, lease)
+	if err != nil {
+		return nil, err
+	}
+	r.mux.Lock()
+	r.claims = append(r.claims, claim)
+	r.mux.Unlock()
+	return claim, nil
+}
+
+func (r *etcdRegistry) Close() {
+	close(r.closed)
+	r.mux.Lock()
+	session := r.session
+	r.mux.Unlock()
+	session.Close()
+	r.client.Close()
+}
+
+// the workerStore methods
+
+func (r *etcdRegistry) createIfAbsent(key string, data []byte, opts ...clientv3.OpOption) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	resp, err := r.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, string(data), opts...)).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return errNodeExists
+	}
+	return nil
+}
+
+func (r *etcdRegistry) createEphemeral(path string, data []byte) error {
+	return r.createIfAbsent(path, data, clientv3.WithLease(r.lease()))
+}
+
+func (r *etcdRegistry) create(path string, data []byte) error {
+	return r.createIfAbsent(path, data)
+}
+
+// get returns the revision of the last change as version.
+func (r *etcdRegistry) get(path string) ([]byte, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	resp, err := r.client.Get(ctx, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, errNoNode
+	}
+	return resp.Kvs[0].Value, resp.Kvs[0].ModRevision, nil
+}
+
+func (r *etcdRegistry) set(path string, data []byte, version int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	resp, err := r.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(path), "=", version)).
+		Then(clientv3.OpPut(path, string(data))).
+		Commit()
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Succeeded {
+		return 0, errBadVersion
+	}
+	return resp.Header.Revision, nil
+}
+
+func (r *etcdRegistry) delete(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	resp, err := r.client.Delete(ctx, path)
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return errNoNode
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// startEtcd runs a single etcd server in the test process and returns its
+// client address.
+func startEtcd(t *testing.T) string {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	client, peer := freeURL(t), freeURL(t)
+	cfg.ListenClientUrls = []url.URL{client}
+	cfg.AdvertiseClientUrls = []url.URL{client}
+	cfg.ListenPeerUrls = []url.URL{peer}
+	cfg.AdvertisePeerUrls = []url.URL{peer}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	server, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatalf("cannot start etcd: %v", err)
+	}
+	t.Cleanup(server.Close)
+	select {
+	case <-server.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("etcd did not start in 10s")
+	}
+	return client.Host
+}
+
+func freeURL(t *testing.T) url.URL {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return url.URL{Scheme: "http", Host: l.Addr().String()}
+}
+
+func newTestEtcdRegistry(t *testing.T, addr string) *etcdRegistry {
+	r, err := newEtcdRegistry([]string{addr}, "/test")
+	if err != nil {
+		t.Fatalf("cannot connect to etcd: %v", err)
+	}
+	t.Cleanup(r.Close)
+	return r
+}
+
+// waitFor polls condition until it holds, failing the test after 5s.
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !condition(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func validity(handler *IdGeneratorHandler) (int64, int64) {
+	handler.mux.Lock()
+	defer handler.mux.Unlock()
+	return handler.notBefore, handler.notAfter
+}
+
+func TestEtcdRegistryPeers(t *testing.T) {
+	addr := startEtcd(t)
+	r1 := newTestEtcdRegistry(t, addr)
+	r2 := newTestEtcdRegistry(t, addr)
+	if err := r1.Register(newServersetMember("10.0.0.1", 3456, 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.Register(newServersetMember("10.0.0.2", 3456, 1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if !r1.Registered() || !r2.Registered() {
+		t.Fatal("registries are not registered")
+	}
+	peers, err := r1.Peers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(peers)
+	if expected := []string{"10.0.0.1:3456", "10.0.0.2:3456"}; !reflect.DeepEqual(peers, expected) {
+		t.Fatalf("peers %v, expected %v", peers, expected)
+	}
+
+	watch, err := r1.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-watch
+	if err := r2.SetStatus(statusWarning); err != nil {
+		t.Fatal(err)
+	}
+	if peers := <-watch; !reflect.DeepEqual(peers, []string{"10.0.0.1:3456"}) {
+		t.Fatalf("peers %v after WARNING, expected only 10.0.0.1:3456", peers)
+	}
+	if err := r2.SetStatus(statusAlive); err != nil {
+		t.Fatal(err)
+	}
+	<-watch
+	if err := r2.Deregister(); err != nil {
+		t.Fatal(err)
+	}
+	if peers := <-watch; !reflect.DeepEqual(peers, []string{"10.0.0.1:3456"}) {
+		t.Fatalf("peers %v after deregistering, expected only 10.0.0.1:3456", peers)
+	}
+}
+
+func TestEtcdClaimWorkerId(t *testing.T) {
+	addr := startEtcd(t)
+	r1 := newTestEtcdRegistry(t, addr)
+	r2 := newTestEtcdRegistry(t, addr)
+	lease := 300 * time.Millisecond
+
+	c1, err := r1.ClaimWorkerId(1, -1, true, "10.0.0.1:3456", lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := r2.ClaimWorkerId(1, -1, true, "10.0.0.2:3456", lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1.workerId != 0 || c2.workerId != 1 {
+		t.Fatalf("claimed worker ids %d and %d, expected 0 and 1", c1.workerId, c2.workerId)
+	}
+	if c1.Epoch() != 1 {
+		t.Fatalf("epoch %d of a new lease, expected 1", c1.Epoch())
+	}
+
+	// renew
+	h1, _ := NewIdGeneratorHandler(c1.workerId, 1)
+	go c1.keepAlive(h1)
+	waitFor(t, "the first renewal", func() bool {
+		_, notAfter := validity(h1)
+		return notAfter > 0
+	})
+	_, first := validity(h1)
+	waitFor(t, "another renewal", func() bool {
+		_, notAfter := validity(h1)
+		return notAfter > first
+	})
+
+	// the lease is renewed, so it cannot be taken over
+	_, err = r2.ClaimWorkerId(1, 0, false, "10.0.0.2:3457", lease)
+	if _, held := err.(*leaseHeldError); !held {
+		t.Fatalf("took over a live lease, error %v", err)
+	}
+
+	// lose: another epoch is written
+	data, version, err := r2.get(c1.leasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var current workerLease
+	json.Unmarshal(data, &current)
+	current.Owner = "10.0.0.2:3457"
+	current.Epoch++
+	data, _ = json.Marshal(current)
+	if _, err := r2.set(c1.leasePath, data, version); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c1.Lost():
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker id 0 not lost after another epoch was written")
+	}
+	if reason := h1.Unavailable(); !strings.Contains(reason, "lost worker id 0") {
+		t.Fatalf("handler unavailable %q after the claim was lost", reason)
+	}
+
+	// the lease of the session expires, the worker id and the registration
+	// come back with a new one
+	h2, _ := NewIdGeneratorHandler(c2.workerId, 1)
+	c2.handler = h2
+	if err := r2.Register(newServersetMember("10.0.0.2", 3456, 1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	expired := r2.lease()
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	if _, err := r2.client.Revoke(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "a new lease", func() bool {
+		return r2.lease() != expired && r2.Registered()
+	})
+	waitFor(t, "the registration to come back", func() bool {
+		peers, _ := r1.Peers()
+		return reflect.DeepEqual(peers, []string{"10.0.0.2:3456"})
+	})
+	if data, _, err := r1.get(c2.path); err != nil || string(data) != "10.0.0.2:3456" {
+		t.Fatalf("worker id node %s %q not created again: %v", c2.path, data, err)
+	}
+	select {
+	case <-c2.Lost():
+		t.Fatal("worker id 1 lost after the lease expired")
+	default:
+	}
+	if reason := h2.Unavailable(); reason != "" {
+		t.Fatalf("handler unavailable %q after the worker id was claimed again", reason)
+	}
+	c1.Release(-1)
+	c2.Release(-1)
+}
@@ -50,26 +50,33 @@ func main() {
 	flag.BoolVar(&useHttp, "http", false, "Use http")
 	flag.Parse()
 
+	// patched after generation: keep the parsed url instead of shadowing it
 	if len(urlString) > 0 {
-		parsedUrl, err := url.Parse(urlString)
+		u, err := url.Parse(urlString)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "Error parsing URL: ", err)
 			flag.Usage()
 		}
+		parsedUrl = *u
 		host = parsedUrl.Host
 		useHttp = len(parsedUrl.Scheme) <= 0 || parsedUrl.Scheme == "http"
 	} else if useHttp {
-		_, err := url.Parse(fmt.Sprint("http://", host, ":", port))
+		if !strings.Contains(host, ":") {
+			host = net.JoinHostPort(host, fmt.Sprint(port))
+		}
+		u, err := url.Parse(fmt.Sprint("http://", host, "/"))
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "Error parsing URL: ", err)
 			flag.Usage()
 		}
+		parsedUrl = *u
 	}
 
 	cmd := flag.Arg(0)
 	var err error
 	if useHttp {
-		trans, err = thrift.NewTHttpClient(parsedUrl.String())
+		// patched after generation: NewTHttpClient only GETs the url
+		trans, err = thrift.NewTHttpPostClient(parsedUrl.String())
 	} else {
 		portStr := fmt.Sprint(port)
 		if strings.Contains(host, ":") {
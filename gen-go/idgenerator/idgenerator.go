@@ -96,16 +96,16 @@ func (p *IdGeneratorClient) recvGetWorkerId() (value int64, err error) {
 		return
 	}
 	if mTypeId == thrift.EXCEPTION {
-		error0 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
-		var error1 error
-		error1, err = error0.Read(iprot)
+		error3 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error4 error
+		error4, err = error3.Read(iprot)
 		if err != nil {
 			return
 		}
 		if err = iprot.ReadMessageEnd(); err != nil {
 			return
 		}
-		err = error1
+		err = error4
 		return
 	}
 	if mTypeId != thrift.REPLY {
@@ -169,16 +169,16 @@ func (p *IdGeneratorClient) recvGetTimestamp() (value int64, err error) {
 		return
 	}
 	if mTypeId == thrift.EXCEPTION {
-		error2 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
-		var error3 error
-		error3, err = error2.Read(iprot)
+		error5 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error6 error
+		error6, err = error5.Read(iprot)
 		if err != nil {
 			return
 		}
 		if err = iprot.ReadMessageEnd(); err != nil {
 			return
 		}
-		err = error3
+		err = error6
 		return
 	}
 	if mTypeId != thrift.REPLY {
@@ -246,16 +246,16 @@ func (p *IdGeneratorClient) recvGetId() (value int64, err error) {
 		return
 	}
 	if mTypeId == thrift.EXCEPTION {
-		error4 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
-		var error5 error
-		error5, err = error4.Read(iprot)
+		error7 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error8 error
+		error8, err = error7.Read(iprot)
 		if err != nil {
 			return
 		}
 		if err = iprot.ReadMessageEnd(); err != nil {
 			return
 		}
-		err = error5
+		err = error8
 		return
 	}
 	if mTypeId != thrift.REPLY {
@@ -269,6 +269,19 @@ func (p *IdGeneratorClient) recvGetId() (value int64, err error) {
 	if err = iprot.ReadMessageEnd(); err != nil {
 		return
 	}
+	if result.ClockMovedBackwards != nil {
+		err = result.ClockMovedBackwards
+		return
+	} else if result.SequenceExhausted != nil {
+		err = result.SequenceExhausted
+		return
+	} else if result.InvalidScope != nil {
+		err = result.InvalidScope
+		return
+	} else if result.ServiceUnavailable != nil {
+		err = result.ServiceUnavailable
+		return
+	}
 	value = result.GetSuccess()
 	return
 }
@@ -319,16 +332,16 @@ func (p *IdGeneratorClient) recvGetDatacenterId() (value int64, err error) {
 		return
 	}
 	if mTypeId == thrift.EXCEPTION {
-		error6 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
-		var error7 error
-		error7, err = error6.Read(iprot)
+		error9 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error10 error
+		error10, err = error9.Read(iprot)
 		if err != nil {
 			return
 		}
 		if err = iprot.ReadMessageEnd(); err != nil {
 			return
 		}
-		err = error7
+		err = error10
 		return
 	}
 	if mTypeId != thrift.REPLY {
@@ -392,16 +405,16 @@ func (p *IdGeneratorClient) recvGetScopes() (value []string, err error) {
 		return
 	}
 	if mTypeId == thrift.EXCEPTION {
-		error8 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
-		var error9 error
-		error9, err = error8.Read(iprot)
+		error11 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error12 error
+		error12, err = error11.Read(iprot)
 		if err != nil {
 			return
 		}
 		if err = iprot.ReadMessageEnd(); err != nil {
 			return
 		}
-		err = error9
+		err = error12
 		return
 	}
 	if mTypeId != thrift.REPLY {
@@ -439,13 +452,13 @@ func (p *IdGeneratorProcessor) ProcessorMap() map[string]thrift.TProcessorFuncti
 
 func NewIdGeneratorProcessor(handler IdGenerator) *IdGeneratorProcessor {
 
-	self10 := &IdGeneratorProcessor{handler: handler, processorMap: make(map[string]thrift.TProcessorFunction)}
-	self10.processorMap["getWorkerId"] = &idGeneratorProcessorGetWorkerId{handler: handler}
-	self10.processorMap["getTimestamp"] = &idGeneratorProcessorGetTimestamp{handler: handler}
-	self10.processorMap["getId"] = &idGeneratorProcessorGetId{handler: handler}
-	self10.processorMap["getDatacenterId"] = &idGeneratorProcessorGetDatacenterId{handler: handler}
-	self10.processorMap["getScopes"] = &idGeneratorProcessorGetScopes{handler: handler}
-	return self10
+	self13 := &IdGeneratorProcessor{handler: handler, processorMap: make(map[string]thrift.TProcessorFunction)}
+	self13.processorMap["getWorkerId"] = &idGeneratorProcessorGetWorkerId{handler: handler}
+	self13.processorMap["getTimestamp"] = &idGeneratorProcessorGetTimestamp{handler: handler}
+	self13.processorMap["getId"] = &idGeneratorProcessorGetId{handler: handler}
+	self13.processorMap["getDatacenterId"] = &idGeneratorProcessorGetDatacenterId{handler: handler}
+	self13.processorMap["getScopes"] = &idGeneratorProcessorGetScopes{handler: handler}
+	return self13
 }
 
 func (p *IdGeneratorProcessor) Process(iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
@@ -458,13 +471,12 @@ func (p *IdGeneratorProcessor) Process(iprot, oprot thrift.TProtocol) (success b
 	}
 	iprot.Skip(thrift.STRUCT)
 	iprot.ReadMessageEnd()
-	x11 := thrift.NewTApplicationException(thrift.UNKNOWN_METHOD, "Unknown function "+name)
+	x14 := thrift.NewTApplicationException(thrift.UNKNOWN_METHOD, "Unknown function "+name)
 	oprot.WriteMessageBegin(name, thrift.EXCEPTION, seqId)
-	x11.Write(oprot)
+	x14.Write(oprot)
 	oprot.WriteMessageEnd()
 	oprot.Flush()
-	// return false, x11
-	return true, x11
+	return false, x14
 }
 
 type idGeneratorProcessorGetWorkerId struct {
@@ -584,12 +596,23 @@ func (p *idGeneratorProcessorGetId) Process(seqId int32, iprot, oprot thrift.TPr
 	var retval int64
 	var err2 error
 	if retval, err2 = p.handler.GetId(args.Scope); err2 != nil {
-		x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing getId: "+err2.Error())
-		oprot.WriteMessageBegin("getId", thrift.EXCEPTION, seqId)
-		x.Write(oprot)
-		oprot.WriteMessageEnd()
-		oprot.Flush()
-		return true, err2
+		switch v := err2.(type) {
+		case *ClockMovedBackwards:
+			result.ClockMovedBackwards = v
+		case *SequenceExhausted:
+			result.SequenceExhausted = v
+		case *InvalidScope:
+			result.InvalidScope = v
+		case *ServiceUnavailable:
+			result.ServiceUnavailable = v
+		default:
+			x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing getId: "+err2.Error())
+			oprot.WriteMessageBegin("getId", thrift.EXCEPTION, seqId)
+			x.Write(oprot)
+			oprot.WriteMessageEnd()
+			oprot.Flush()
+			return true, err2
+		}
 	} else {
 		result.Success = &retval
 	}
@@ -1112,8 +1135,16 @@ func (p *IdGeneratorGetIdArgs) String() string {
 
 // Attributes:
 //  - Success
+//  - ClockMovedBackwards
+//  - SequenceExhausted
+//  - InvalidScope
+//  - ServiceUnavailable
 type IdGeneratorGetIdResult struct {
-	Success *int64 `thrift:"success,0" json:"success,omitempty"`
+	Success             *int64               `thrift:"success,0" json:"success,omitempty"`
+	ClockMovedBackwards *ClockMovedBackwards `thrift:"clockMovedBackwards,1" json:"clockMovedBackwards,omitempty"`
+	SequenceExhausted   *SequenceExhausted   `thrift:"sequenceExhausted,2" json:"sequenceExhausted,omitempty"`
+	InvalidScope        *InvalidScope        `thrift:"invalidScope,3" json:"invalidScope,omitempty"`
+	ServiceUnavailable  *ServiceUnavailable  `thrift:"serviceUnavailable,4" json:"serviceUnavailable,omitempty"`
 }
 
 func NewIdGeneratorGetIdResult() *IdGeneratorGetIdResult {
@@ -1128,9 +1159,57 @@ func (p *IdGeneratorGetIdResult) GetSuccess() int64 {
 	}
 	return *p.Success
 }
+
+var IdGeneratorGetIdResult_ClockMovedBackwards_DEFAULT *ClockMovedBackwards
+
+func (p *IdGeneratorGetIdResult) GetClockMovedBackwards() *ClockMovedBackwards {
+	if !p.IsSetClockMovedBackwards() {
+		return IdGeneratorGetIdResult_ClockMovedBackwards_DEFAULT
+	}
+	return p.ClockMovedBackwards
+}
+
+var IdGeneratorGetIdResult_SequenceExhausted_DEFAULT *SequenceExhausted
+
+func (p *IdGeneratorGetIdResult) GetSequenceExhausted() *SequenceExhausted {
+	if !p.IsSetSequenceExhausted() {
+		return IdGeneratorGetIdResult_SequenceExhausted_DEFAULT
+	}
+	return p.SequenceExhausted
+}
+
+var IdGeneratorGetIdResult_InvalidScope_DEFAULT *InvalidScope
+
+func (p *IdGeneratorGetIdResult) GetInvalidScope() *InvalidScope {
+	if !p.IsSetInvalidScope() {
+		return IdGeneratorGetIdResult_InvalidScope_DEFAULT
+	}
+	return p.InvalidScope
+}
+
+var IdGeneratorGetIdResult_ServiceUnavailable_DEFAULT *ServiceUnavailable
+
+func (p *IdGeneratorGetIdResult) GetServiceUnavailable() *ServiceUnavailable {
+	if !p.IsSetServiceUnavailable() {
+		return IdGeneratorGetIdResult_ServiceUnavailable_DEFAULT
+	}
+	return p.ServiceUnavailable
+}
 func (p *IdGeneratorGetIdResult) IsSetSuccess() bool {
 	return p.Success != nil
 }
+func (p *IdGeneratorGetIdResult) IsSetClockMovedBackwards() bool {
+	return p.ClockMovedBackwards != nil
+}
+func (p *IdGeneratorGetIdResult) IsSetSequenceExhausted() bool {
+	return p.SequenceExhausted != nil
+}
+func (p *IdGeneratorGetIdResult) IsSetInvalidScope() bool {
+	return p.InvalidScope != nil
+}
+func (p *IdGeneratorGetIdResult) IsSetServiceUnavailable() bool {
+	return p.ServiceUnavailable != nil
+}
 
 func (p *IdGeneratorGetIdResult) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
@@ -1150,6 +1229,22 @@ func (p *IdGeneratorGetIdResult) Read(iprot thrift.TProtocol) error {
 			if err := p.readField0(iprot); err != nil {
 				return err
 			}
+		case 1:
+			if err := p.readField1(iprot); err != nil {
+				return err
+			}
+		case 2:
+			if err := p.readField2(iprot); err != nil {
+				return err
+			}
+		case 3:
+			if err := p.readField3(iprot); err != nil {
+				return err
+			}
+		case 4:
+			if err := p.readField4(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -1174,6 +1269,38 @@ func (p *IdGeneratorGetIdResult) readField0(iprot thrift.TProtocol) error {
 	return nil
 }
 
+func (p *IdGeneratorGetIdResult) readField1(iprot thrift.TProtocol) error {
+	p.ClockMovedBackwards = &ClockMovedBackwards{}
+	if err := p.ClockMovedBackwards.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.ClockMovedBackwards), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorGetIdResult) readField2(iprot thrift.TProtocol) error {
+	p.SequenceExhausted = &SequenceExhausted{}
+	if err := p.SequenceExhausted.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.SequenceExhausted), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorGetIdResult) readField3(iprot thrift.TProtocol) error {
+	p.InvalidScope = &InvalidScope{}
+	if err := p.InvalidScope.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.InvalidScope), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorGetIdResult) readField4(iprot thrift.TProtocol) error {
+	p.ServiceUnavailable = &ServiceUnavailable{}
+	if err := p.ServiceUnavailable.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.ServiceUnavailable), err)
+	}
+	return nil
+}
+
 func (p *IdGeneratorGetIdResult) Write(oprot thrift.TProtocol) error {
 	if err := oprot.WriteStructBegin("getId_result"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
@@ -1181,6 +1308,18 @@ func (p *IdGeneratorGetIdResult) Write(oprot thrift.TProtocol) error {
 	if err := p.writeField0(oprot); err != nil {
 		return err
 	}
+	if err := p.writeField1(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField2(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField3(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField4(oprot); err != nil {
+		return err
+	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
 	}
@@ -1205,6 +1344,66 @@ func (p *IdGeneratorGetIdResult) writeField0(oprot thrift.TProtocol) (err error)
 	return err
 }
 
+func (p *IdGeneratorGetIdResult) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetClockMovedBackwards() {
+		if err := oprot.WriteFieldBegin("clockMovedBackwards", thrift.STRUCT, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:clockMovedBackwards: ", p), err)
+		}
+		if err := p.ClockMovedBackwards.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.ClockMovedBackwards), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:clockMovedBackwards: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorGetIdResult) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSequenceExhausted() {
+		if err := oprot.WriteFieldBegin("sequenceExhausted", thrift.STRUCT, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:sequenceExhausted: ", p), err)
+		}
+		if err := p.SequenceExhausted.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.SequenceExhausted), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:sequenceExhausted: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorGetIdResult) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetInvalidScope() {
+		if err := oprot.WriteFieldBegin("invalidScope", thrift.STRUCT, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:invalidScope: ", p), err)
+		}
+		if err := p.InvalidScope.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.InvalidScope), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:invalidScope: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorGetIdResult) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetServiceUnavailable() {
+		if err := oprot.WriteFieldBegin("serviceUnavailable", thrift.STRUCT, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:serviceUnavailable: ", p), err)
+		}
+		if err := p.ServiceUnavailable.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.ServiceUnavailable), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:serviceUnavailable: ", p), err)
+		}
+	}
+	return err
+}
+
 func (p *IdGeneratorGetIdResult) String() string {
 	if p == nil {
 		return "<nil>"
@@ -1480,13 +1679,13 @@ func (p *IdGeneratorGetScopesResult) readField0(iprot thrift.TProtocol) error {
 	tSlice := make([]string, 0, size)
 	p.Success = tSlice
 	for i := 0; i < size; i++ {
-		var _elem12 string
+		var _elem15 string
 		if v, err := iprot.ReadString(); err != nil {
 			return thrift.PrependError("error reading field 0: ", err)
 		} else {
-			_elem12 = v
+			_elem15 = v
 		}
-		p.Success = append(p.Success, _elem12)
+		p.Success = append(p.Success, _elem15)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -0,0 +1,945 @@
+// Autogenerated by Thrift Compiler (0.9.3)
+// DO NOT EDIT UNLESS YOU ARE SURE THAT YOU KNOW WHAT YOU ARE DOING
+
+package idgenerator
+
+import (
+	"bytes"
+	"fmt"
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// (needed to ensure safety because of naive import list construction.)
+var _ = thrift.ZERO
+var _ = fmt.Printf
+var _ = bytes.Equal
+
+type IdGeneratorV2 interface {
+	// Parameters:
+	//  - Request
+	GetIds(request *GetIdsRequest) (r *GetIdsResponse, err error)
+	GetInfo() (r *ServerInfo, err error)
+}
+
+type IdGeneratorV2Client struct {
+	Transport       thrift.TTransport
+	ProtocolFactory thrift.TProtocolFactory
+	InputProtocol   thrift.TProtocol
+	OutputProtocol  thrift.TProtocol
+	SeqId           int32
+}
+
+func NewIdGeneratorV2ClientFactory(t thrift.TTransport, f thrift.TProtocolFactory) *IdGeneratorV2Client {
+	return &IdGeneratorV2Client{Transport: t,
+		ProtocolFactory: f,
+		InputProtocol:   f.GetProtocol(t),
+		OutputProtocol:  f.GetProtocol(t),
+		SeqId:           0,
+	}
+}
+
+func NewIdGeneratorV2ClientProtocol(t thrift.TTransport, iprot thrift.TProtocol, oprot thrift.TProtocol) *IdGeneratorV2Client {
+	return &IdGeneratorV2Client{Transport: t,
+		ProtocolFactory: nil,
+		InputProtocol:   iprot,
+		OutputProtocol:  oprot,
+		SeqId:           0,
+	}
+}
+
+// Parameters:
+//  - Request
+func (p *IdGeneratorV2Client) GetIds(request *GetIdsRequest) (r *GetIdsResponse, err error) {
+	if err = p.sendGetIds(request); err != nil {
+		return
+	}
+	return p.recvGetIds()
+}
+
+func (p *IdGeneratorV2Client) sendGetIds(request *GetIdsRequest) (err error) {
+	oprot := p.OutputProtocol
+	if oprot == nil {
+		oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.OutputProtocol = oprot
+	}
+	p.SeqId++
+	if err = oprot.WriteMessageBegin("getIds", thrift.CALL, p.SeqId); err != nil {
+		return
+	}
+	args := IdGeneratorV2GetIdsArgs{
+		Request: request,
+	}
+	if err = args.Write(oprot); err != nil {
+		return
+	}
+	if err = oprot.WriteMessageEnd(); err != nil {
+		return
+	}
+	return oprot.Flush()
+}
+
+func (p *IdGeneratorV2Client) recvGetIds() (value *GetIdsResponse, err error) {
+	iprot := p.InputProtocol
+	if iprot == nil {
+		iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.InputProtocol = iprot
+	}
+	method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return
+	}
+	if method != "getIds" {
+		err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "getIds failed: wrong method name")
+		return
+	}
+	if p.SeqId != seqId {
+		err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "getIds failed: out of sequence response")
+		return
+	}
+	if mTypeId == thrift.EXCEPTION {
+		error16 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error17 error
+		error17, err = error16.Read(iprot)
+		if err != nil {
+			return
+		}
+		if err = iprot.ReadMessageEnd(); err != nil {
+			return
+		}
+		err = error17
+		return
+	}
+	if mTypeId != thrift.REPLY {
+		err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "getIds failed: invalid message type")
+		return
+	}
+	result := IdGeneratorV2GetIdsResult{}
+	if err = result.Read(iprot); err != nil {
+		return
+	}
+	if err = iprot.ReadMessageEnd(); err != nil {
+		return
+	}
+	if result.ClockMovedBackwards != nil {
+		err = result.ClockMovedBackwards
+		return
+	} else if result.SequenceExhausted != nil {
+		err = result.SequenceExhausted
+		return
+	} else if result.InvalidScope != nil {
+		err = result.InvalidScope
+		return
+	} else if result.ServiceUnavailable != nil {
+		err = result.ServiceUnavailable
+		return
+	} else if result.InvalidRequest != nil {
+		err = result.InvalidRequest
+		return
+	}
+	value = result.GetSuccess()
+	return
+}
+
+func (p *IdGeneratorV2Client) GetInfo() (r *ServerInfo, err error) {
+	if err = p.sendGetInfo(); err != nil {
+		return
+	}
+	return p.recvGetInfo()
+}
+
+func (p *IdGeneratorV2Client) sendGetInfo() (err error) {
+	oprot := p.OutputProtocol
+	if oprot == nil {
+		oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.OutputProtocol = oprot
+	}
+	p.SeqId++
+	if err = oprot.WriteMessageBegin("getInfo", thrift.CALL, p.SeqId); err != nil {
+		return
+	}
+	args := IdGeneratorV2GetInfoArgs{}
+	if err = args.Write(oprot); err != nil {
+		return
+	}
+	if err = oprot.WriteMessageEnd(); err != nil {
+		return
+	}
+	return oprot.Flush()
+}
+
+func (p *IdGeneratorV2Client) recvGetInfo() (value *ServerInfo, err error) {
+	iprot := p.InputProtocol
+	if iprot == nil {
+		iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.InputProtocol = iprot
+	}
+	method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return
+	}
+	if method != "getInfo" {
+		err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "getInfo failed: wrong method name")
+		return
+	}
+	if p.SeqId != seqId {
+		err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "getInfo failed: out of sequence response")
+		return
+	}
+	if mTypeId == thrift.EXCEPTION {
+		error18 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error19 error
+		error19, err = error18.Read(iprot)
+		if err != nil {
+			return
+		}
+		if err = iprot.ReadMessageEnd(); err != nil {
+			return
+		}
+		err = error19
+		return
+	}
+	if mTypeId != thrift.REPLY {
+		err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "getInfo failed: invalid message type")
+		return
+	}
+	result := IdGeneratorV2GetInfoResult{}
+	if err = result.Read(iprot); err != nil {
+		return
+	}
+	if err = iprot.ReadMessageEnd(); err != nil {
+		return
+	}
+	value = result.GetSuccess()
+	return
+}
+
+type IdGeneratorV2Processor struct {
+	processorMap map[string]thrift.TProcessorFunction
+	handler      IdGeneratorV2
+}
+
+func (p *IdGeneratorV2Processor) AddToProcessorMap(key string, processor thrift.TProcessorFunction) {
+	p.processorMap[key] = processor
+}
+
+func (p *IdGeneratorV2Processor) GetProcessorFunction(key string) (processor thrift.TProcessorFunction, ok bool) {
+	processor, ok = p.processorMap[key]
+	return processor, ok
+}
+
+func (p *IdGeneratorV2Processor) ProcessorMap() map[string]thrift.TProcessorFunction {
+	return p.processorMap
+}
+
+func NewIdGeneratorV2Processor(handler IdGeneratorV2) *IdGeneratorV2Processor {
+
+	self20 := &IdGeneratorV2Processor{handler: handler, processorMap: make(map[string]thrift.TProcessorFunction)}
+	self20.processorMap["getIds"] = &idGeneratorV2ProcessorGetIds{handler: handler}
+	self20.processorMap["getInfo"] = &idGeneratorV2ProcessorGetInfo{handler: handler}
+	return self20
+}
+
+func (p *IdGeneratorV2Processor) Process(iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+	name, _, seqId, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return false, err
+	}
+	if processor, ok := p.GetProcessorFunction(name); ok {
+		return processor.Process(seqId, iprot, oprot)
+	}
+	iprot.Skip(thrift.STRUCT)
+	iprot.ReadMessageEnd()
+	x21 := thrift.NewTApplicationException(thrift.UNKNOWN_METHOD, "Unknown function "+name)
+	oprot.WriteMessageBegin(name, thrift.EXCEPTION, seqId)
+	x21.Write(oprot)
+	oprot.WriteMessageEnd()
+	oprot.Flush()
+	return false, x21
+}
+
+type idGeneratorV2ProcessorGetIds struct {
+	handler IdGeneratorV2
+}
+
+func (p *idGeneratorV2ProcessorGetIds) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+	args := IdGeneratorV2GetIdsArgs{}
+	if err = args.Read(iprot); err != nil {
+		iprot.ReadMessageEnd()
+		x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+		oprot.WriteMessageBegin("getIds", thrift.EXCEPTION, seqId)
+		x.Write(oprot)
+		oprot.WriteMessageEnd()
+		oprot.Flush()
+		return false, err
+	}
+
+	iprot.ReadMessageEnd()
+	result := IdGeneratorV2GetIdsResult{}
+	var retval *GetIdsResponse
+	var err2 error
+	if retval, err2 = p.handler.GetIds(args.Request); err2 != nil {
+		switch v := err2.(type) {
+		case *ClockMovedBackwards:
+			result.ClockMovedBackwards = v
+		case *SequenceExhausted:
+			result.SequenceExhausted = v
+		case *InvalidScope:
+			result.InvalidScope = v
+		case *ServiceUnavailable:
+			result.ServiceUnavailable = v
+		case *InvalidRequest:
+			result.InvalidRequest = v
+		default:
+			x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing getIds: "+err2.Error())
+			oprot.WriteMessageBegin("getIds", thrift.EXCEPTION, seqId)
+			x.Write(oprot)
+			oprot.WriteMessageEnd()
+			oprot.Flush()
+			return true, err2
+		}
+	} else {
+		result.Success = retval
+	}
+	if err2 = oprot.WriteMessageBegin("getIds", thrift.REPLY, seqId); err2 != nil {
+		err = err2
+	}
+	if err2 = result.Write(oprot); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.Flush(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err != nil {
+		return
+	}
+	return true, err
+}
+
+type idGeneratorV2ProcessorGetInfo struct {
+	handler IdGeneratorV2
+}
+
+func (p *idGeneratorV2ProcessorGetInfo) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+	args := IdGeneratorV2GetInfoArgs{}
+	if err = args.Read(iprot); err != nil {
+		iprot.ReadMessageEnd()
+		x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+		oprot.WriteMessageBegin("getInfo", thrift.EXCEPTION, seqId)
+		x.Write(oprot)
+		oprot.WriteMessageEnd()
+		oprot.Flush()
+		return false, err
+	}
+
+	iprot.ReadMessageEnd()
+	result := IdGeneratorV2GetInfoResult{}
+	var retval *ServerInfo
+	var err2 error
+	if retval, err2 = p.handler.GetInfo(); err2 != nil {
+		x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing getInfo: "+err2.Error())
+		oprot.WriteMessageBegin("getInfo", thrift.EXCEPTION, seqId)
+		x.Write(oprot)
+		oprot.WriteMessageEnd()
+		oprot.Flush()
+		return true, err2
+	} else {
+		result.Success = retval
+	}
+	if err2 = oprot.WriteMessageBegin("getInfo", thrift.REPLY, seqId); err2 != nil {
+		err = err2
+	}
+	if err2 = result.Write(oprot); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.Flush(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err != nil {
+		return
+	}
+	return true, err
+}
+
+// HELPER FUNCTIONS AND STRUCTURES
+
+// Attributes:
+//  - Request
+type IdGeneratorV2GetIdsArgs struct {
+	Request *GetIdsRequest `thrift:"request,1" json:"request"`
+}
+
+func NewIdGeneratorV2GetIdsArgs() *IdGeneratorV2GetIdsArgs {
+	return &IdGeneratorV2GetIdsArgs{}
+}
+
+var IdGeneratorV2GetIdsArgs_Request_DEFAULT *GetIdsRequest
+
+func (p *IdGeneratorV2GetIdsArgs) GetRequest() *GetIdsRequest {
+	if !p.IsSetRequest() {
+		return IdGeneratorV2GetIdsArgs_Request_DEFAULT
+	}
+	return p.Request
+}
+func (p *IdGeneratorV2GetIdsArgs) IsSetRequest() bool {
+	return p.Request != nil
+}
+
+func (p *IdGeneratorV2GetIdsArgs) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.readField1(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsArgs) readField1(iprot thrift.TProtocol) error {
+	p.Request = &GetIdsRequest{}
+	if err := p.Request.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Request), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsArgs) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("getIds_args"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := p.writeField1(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsArgs) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetRequest() {
+		if err := oprot.WriteFieldBegin("request", thrift.STRUCT, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:request: ", p), err)
+		}
+		if err := p.Request.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Request), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:request: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorV2GetIdsArgs) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("IdGeneratorV2GetIdsArgs(%+v)", *p)
+}
+
+// Attributes:
+//  - Success
+//  - ClockMovedBackwards
+//  - SequenceExhausted
+//  - InvalidScope
+//  - ServiceUnavailable
+//  - InvalidRequest
+type IdGeneratorV2GetIdsResult struct {
+	Success             *GetIdsResponse      `thrift:"success,0" json:"success,omitempty"`
+	ClockMovedBackwards *ClockMovedBackwards `thrift:"clockMovedBackwards,1" json:"clockMovedBackwards,omitempty"`
+	SequenceExhausted   *SequenceExhausted   `thrift:"sequenceExhausted,2" json:"sequenceExhausted,omitempty"`
+	InvalidScope        *InvalidScope        `thrift:"invalidScope,3" json:"invalidScope,omitempty"`
+	ServiceUnavailable  *ServiceUnavailable  `thrift:"serviceUnavailable,4" json:"serviceUnavailable,omitempty"`
+	InvalidRequest      *InvalidRequest      `thrift:"invalidRequest,5" json:"invalidRequest,omitempty"`
+}
+
+func NewIdGeneratorV2GetIdsResult() *IdGeneratorV2GetIdsResult {
+	return &IdGeneratorV2GetIdsResult{}
+}
+
+var IdGeneratorV2GetIdsResult_Success_DEFAULT *GetIdsResponse
+
+func (p *IdGeneratorV2GetIdsResult) GetSuccess() *GetIdsResponse {
+	if !p.IsSetSuccess() {
+		return IdGeneratorV2GetIdsResult_Success_DEFAULT
+	}
+	return p.Success
+}
+
+var IdGeneratorV2GetIdsResult_ClockMovedBackwards_DEFAULT *ClockMovedBackwards
+
+func (p *IdGeneratorV2GetIdsResult) GetClockMovedBackwards() *ClockMovedBackwards {
+	if !p.IsSetClockMovedBackwards() {
+		return IdGeneratorV2GetIdsResult_ClockMovedBackwards_DEFAULT
+	}
+	return p.ClockMovedBackwards
+}
+
+var IdGeneratorV2GetIdsResult_SequenceExhausted_DEFAULT *SequenceExhausted
+
+func (p *IdGeneratorV2GetIdsResult) GetSequenceExhausted() *SequenceExhausted {
+	if !p.IsSetSequenceExhausted() {
+		return IdGeneratorV2GetIdsResult_SequenceExhausted_DEFAULT
+	}
+	return p.SequenceExhausted
+}
+
+var IdGeneratorV2GetIdsResult_InvalidScope_DEFAULT *InvalidScope
+
+func (p *IdGeneratorV2GetIdsResult) GetInvalidScope() *InvalidScope {
+	if !p.IsSetInvalidScope() {
+		return IdGeneratorV2GetIdsResult_InvalidScope_DEFAULT
+	}
+	return p.InvalidScope
+}
+
+var IdGeneratorV2GetIdsResult_ServiceUnavailable_DEFAULT *ServiceUnavailable
+
+func (p *IdGeneratorV2GetIdsResult) GetServiceUnavailable() *ServiceUnavailable {
+	if !p.IsSetServiceUnavailable() {
+		return IdGeneratorV2GetIdsResult_ServiceUnavailable_DEFAULT
+	}
+	return p.ServiceUnavailable
+}
+
+var IdGeneratorV2GetIdsResult_InvalidRequest_DEFAULT *InvalidRequest
+
+func (p *IdGeneratorV2GetIdsResult) GetInvalidRequest() *InvalidRequest {
+	if !p.IsSetInvalidRequest() {
+		return IdGeneratorV2GetIdsResult_InvalidRequest_DEFAULT
+	}
+	return p.InvalidRequest
+}
+func (p *IdGeneratorV2GetIdsResult) IsSetSuccess() bool {
+	return p.Success != nil
+}
+func (p *IdGeneratorV2GetIdsResult) IsSetClockMovedBackwards() bool {
+	return p.ClockMovedBackwards != nil
+}
+func (p *IdGeneratorV2GetIdsResult) IsSetSequenceExhausted() bool {
+	return p.SequenceExhausted != nil
+}
+func (p *IdGeneratorV2GetIdsResult) IsSetInvalidScope() bool {
+	return p.InvalidScope != nil
+}
+func (p *IdGeneratorV2GetIdsResult) IsSetServiceUnavailable() bool {
+	return p.ServiceUnavailable != nil
+}
+func (p *IdGeneratorV2GetIdsResult) IsSetInvalidRequest() bool {
+	return p.InvalidRequest != nil
+}
+
+func (p *IdGeneratorV2GetIdsResult) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 0:
+			if err := p.readField0(iprot); err != nil {
+				return err
+			}
+		case 1:
+			if err := p.readField1(iprot); err != nil {
+				return err
+			}
+		case 2:
+			if err := p.readField2(iprot); err != nil {
+				return err
+			}
+		case 3:
+			if err := p.readField3(iprot); err != nil {
+				return err
+			}
+		case 4:
+			if err := p.readField4(iprot); err != nil {
+				return err
+			}
+		case 5:
+			if err := p.readField5(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsResult) readField0(iprot thrift.TProtocol) error {
+	p.Success = &GetIdsResponse{}
+	if err := p.Success.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Success), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsResult) readField1(iprot thrift.TProtocol) error {
+	p.ClockMovedBackwards = &ClockMovedBackwards{}
+	if err := p.ClockMovedBackwards.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.ClockMovedBackwards), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsResult) readField2(iprot thrift.TProtocol) error {
+	p.SequenceExhausted = &SequenceExhausted{}
+	if err := p.SequenceExhausted.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.SequenceExhausted), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsResult) readField3(iprot thrift.TProtocol) error {
+	p.InvalidScope = &InvalidScope{}
+	if err := p.InvalidScope.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.InvalidScope), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsResult) readField4(iprot thrift.TProtocol) error {
+	p.ServiceUnavailable = &ServiceUnavailable{}
+	if err := p.ServiceUnavailable.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.ServiceUnavailable), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsResult) readField5(iprot thrift.TProtocol) error {
+	p.InvalidRequest = &InvalidRequest{}
+	if err := p.InvalidRequest.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.InvalidRequest), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsResult) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("getIds_result"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := p.writeField0(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField1(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField2(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField3(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField4(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField5(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetIdsResult) writeField0(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSuccess() {
+		if err := oprot.WriteFieldBegin("success", thrift.STRUCT, 0); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err)
+		}
+		if err := p.Success.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Success), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorV2GetIdsResult) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetClockMovedBackwards() {
+		if err := oprot.WriteFieldBegin("clockMovedBackwards", thrift.STRUCT, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:clockMovedBackwards: ", p), err)
+		}
+		if err := p.ClockMovedBackwards.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.ClockMovedBackwards), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:clockMovedBackwards: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorV2GetIdsResult) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSequenceExhausted() {
+		if err := oprot.WriteFieldBegin("sequenceExhausted", thrift.STRUCT, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:sequenceExhausted: ", p), err)
+		}
+		if err := p.SequenceExhausted.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.SequenceExhausted), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:sequenceExhausted: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorV2GetIdsResult) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetInvalidScope() {
+		if err := oprot.WriteFieldBegin("invalidScope", thrift.STRUCT, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:invalidScope: ", p), err)
+		}
+		if err := p.InvalidScope.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.InvalidScope), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:invalidScope: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorV2GetIdsResult) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetServiceUnavailable() {
+		if err := oprot.WriteFieldBegin("serviceUnavailable", thrift.STRUCT, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:serviceUnavailable: ", p), err)
+		}
+		if err := p.ServiceUnavailable.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.ServiceUnavailable), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:serviceUnavailable: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorV2GetIdsResult) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetInvalidRequest() {
+		if err := oprot.WriteFieldBegin("invalidRequest", thrift.STRUCT, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:invalidRequest: ", p), err)
+		}
+		if err := p.InvalidRequest.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.InvalidRequest), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:invalidRequest: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorV2GetIdsResult) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("IdGeneratorV2GetIdsResult(%+v)", *p)
+}
+
+type IdGeneratorV2GetInfoArgs struct {
+}
+
+func NewIdGeneratorV2GetInfoArgs() *IdGeneratorV2GetInfoArgs {
+	return &IdGeneratorV2GetInfoArgs{}
+}
+
+func (p *IdGeneratorV2GetInfoArgs) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		if err := iprot.Skip(fieldTypeId); err != nil {
+			return err
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetInfoArgs) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("getInfo_args"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetInfoArgs) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("IdGeneratorV2GetInfoArgs(%+v)", *p)
+}
+
+// Attributes:
+//  - Success
+type IdGeneratorV2GetInfoResult struct {
+	Success *ServerInfo `thrift:"success,0" json:"success,omitempty"`
+}
+
+func NewIdGeneratorV2GetInfoResult() *IdGeneratorV2GetInfoResult {
+	return &IdGeneratorV2GetInfoResult{}
+}
+
+var IdGeneratorV2GetInfoResult_Success_DEFAULT *ServerInfo
+
+func (p *IdGeneratorV2GetInfoResult) GetSuccess() *ServerInfo {
+	if !p.IsSetSuccess() {
+		return IdGeneratorV2GetInfoResult_Success_DEFAULT
+	}
+	return p.Success
+}
+func (p *IdGeneratorV2GetInfoResult) IsSetSuccess() bool {
+	return p.Success != nil
+}
+
+func (p *IdGeneratorV2GetInfoResult) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 0:
+			if err := p.readField0(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetInfoResult) readField0(iprot thrift.TProtocol) error {
+	p.Success = &ServerInfo{}
+	if err := p.Success.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Success), err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetInfoResult) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("getInfo_result"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := p.writeField0(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *IdGeneratorV2GetInfoResult) writeField0(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSuccess() {
+		if err := oprot.WriteFieldBegin("success", thrift.STRUCT, 0); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err)
+		}
+		if err := p.Success.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Success), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *IdGeneratorV2GetInfoResult) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("IdGeneratorV2GetInfoResult(%+v)", *p)
+}
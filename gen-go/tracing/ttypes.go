@@ -0,0 +1,1083 @@
+// Autogenerated by Thrift Compiler (0.9.3)
+// DO NOT EDIT UNLESS YOU ARE SURE THAT YOU KNOW WHAT YOU ARE DOING
+
+package tracing
+
+import (
+	"bytes"
+	"fmt"
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// (needed to ensure safety because of naive import list construction.)
+var _ = thrift.ZERO
+var _ = fmt.Printf
+var _ = bytes.Equal
+
+var GoUnusedProtection__ int
+
+// Attributes:
+//  - Name
+type ClientId struct {
+	Name string `thrift:"name,1" json:"name"`
+}
+
+func NewClientId() *ClientId {
+	return &ClientId{}
+}
+
+func (p *ClientId) GetName() string {
+	return p.Name
+}
+func (p *ClientId) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.readField1(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *ClientId) readField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.Name = v
+	}
+	return nil
+}
+
+func (p *ClientId) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("ClientId"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := p.writeField1(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *ClientId) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("name", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:name: ", p), err)
+	}
+	if err := oprot.WriteString(string(p.Name)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.name (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:name: ", p), err)
+	}
+	return err
+}
+
+func (p *ClientId) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("ClientId(%+v)", *p)
+}
+
+// Attributes:
+//  - Key
+//  - Value
+type RequestContext struct {
+	Key   []byte `thrift:"key,1" json:"key"`
+	Value []byte `thrift:"value,2" json:"value"`
+}
+
+func NewRequestContext() *RequestContext {
+	return &RequestContext{}
+}
+
+func (p *RequestContext) GetKey() []byte {
+	return p.Key
+}
+func (p *RequestContext) GetValue() []byte {
+	return p.Value
+}
+func (p *RequestContext) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.readField1(iprot); err != nil {
+				return err
+			}
+		case 2:
+			if err := p.readField2(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *RequestContext) readField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.Key = v
+	}
+	return nil
+}
+
+func (p *RequestContext) readField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.Value = v
+	}
+	return nil
+}
+
+func (p *RequestContext) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("RequestContext"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := p.writeField1(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField2(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *RequestContext) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("key", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:key: ", p), err)
+	}
+	if err := oprot.WriteBinary([]byte(p.Key)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.key (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:key: ", p), err)
+	}
+	return err
+}
+
+func (p *RequestContext) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("value", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:value: ", p), err)
+	}
+	if err := oprot.WriteBinary([]byte(p.Value)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.value (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:value: ", p), err)
+	}
+	return err
+}
+
+func (p *RequestContext) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("RequestContext(%+v)", *p)
+}
+
+// Attributes:
+//  - Src
+//  - Dst
+type Delegation struct {
+	Src string `thrift:"src,1" json:"src"`
+	Dst string `thrift:"dst,2" json:"dst"`
+}
+
+func NewDelegation() *Delegation {
+	return &Delegation{}
+}
+
+func (p *Delegation) GetSrc() string {
+	return p.Src
+}
+func (p *Delegation) GetDst() string {
+	return p.Dst
+}
+func (p *Delegation) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.readField1(iprot); err != nil {
+				return err
+			}
+		case 2:
+			if err := p.readField2(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *Delegation) readField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.Src = v
+	}
+	return nil
+}
+
+func (p *Delegation) readField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.Dst = v
+	}
+	return nil
+}
+
+func (p *Delegation) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("Delegation"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := p.writeField1(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField2(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *Delegation) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("src", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:src: ", p), err)
+	}
+	if err := oprot.WriteString(string(p.Src)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.src (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:src: ", p), err)
+	}
+	return err
+}
+
+func (p *Delegation) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("dst", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:dst: ", p), err)
+	}
+	if err := oprot.WriteString(string(p.Dst)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.dst (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:dst: ", p), err)
+	}
+	return err
+}
+
+func (p *Delegation) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("Delegation(%+v)", *p)
+}
+
+// Attributes:
+//  - TraceID
+//  - SpanID
+//  - ParentSpanID
+//  - Sampled
+//  - ClientID
+//  - Flags
+//  - Contexts
+//  - Dest
+//  - Delegations
+//  - TraceIDHigh
+type RequestHeader struct {
+	TraceID      int64             `thrift:"trace_id,1" json:"trace_id"`
+	SpanID       int64             `thrift:"span_id,2" json:"span_id"`
+	ParentSpanID *int64            `thrift:"parent_span_id,3" json:"parent_span_id,omitempty"`
+	Sampled      *bool             `thrift:"sampled,5" json:"sampled,omitempty"`
+	ClientID     *ClientId         `thrift:"client_id,6" json:"client_id,omitempty"`
+	Flags        *int64            `thrift:"flags,7" json:"flags,omitempty"`
+	Contexts     []*RequestContext `thrift:"contexts,8" json:"contexts"`
+	Dest         *string           `thrift:"dest,9" json:"dest,omitempty"`
+	Delegations  []*Delegation     `thrift:"delegations,10" json:"delegations,omitempty"`
+	TraceIDHigh  *int64            `thrift:"trace_id_high,11" json:"trace_id_high,omitempty"`
+}
+
+func NewRequestHeader() *RequestHeader {
+	return &RequestHeader{}
+}
+
+func (p *RequestHeader) GetTraceID() int64 {
+	return p.TraceID
+}
+func (p *RequestHeader) GetSpanID() int64 {
+	return p.SpanID
+}
+
+var RequestHeader_ParentSpanID_DEFAULT int64
+
+func (p *RequestHeader) GetParentSpanID() int64 {
+	if !p.IsSetParentSpanID() {
+		return RequestHeader_ParentSpanID_DEFAULT
+	}
+	return *p.ParentSpanID
+}
+
+var RequestHeader_Sampled_DEFAULT bool
+
+func (p *RequestHeader) GetSampled() bool {
+	if !p.IsSetSampled() {
+		return RequestHeader_Sampled_DEFAULT
+	}
+	return *p.Sampled
+}
+
+var RequestHeader_ClientID_DEFAULT *ClientId
+
+func (p *RequestHeader) GetClientID() *ClientId {
+	if !p.IsSetClientID() {
+		return RequestHeader_ClientID_DEFAULT
+	}
+	return p.ClientID
+}
+
+var RequestHeader_Flags_DEFAULT int64
+
+func (p *RequestHeader) GetFlags() int64 {
+	if !p.IsSetFlags() {
+		return RequestHeader_Flags_DEFAULT
+	}
+	return *p.Flags
+}
+func (p *RequestHeader) GetContexts() []*RequestContext {
+	return p.Contexts
+}
+
+var RequestHeader_Dest_DEFAULT string
+
+func (p *RequestHeader) GetDest() string {
+	if !p.IsSetDest() {
+		return RequestHeader_Dest_DEFAULT
+	}
+	return *p.Dest
+}
+
+var RequestHeader_Delegations_DEFAULT []*Delegation
+
+func (p *RequestHeader) GetDelegations() []*Delegation {
+	return p.Delegations
+}
+
+var RequestHeader_TraceIDHigh_DEFAULT int64
+
+func (p *RequestHeader) GetTraceIDHigh() int64 {
+	if !p.IsSetTraceIDHigh() {
+		return RequestHeader_TraceIDHigh_DEFAULT
+	}
+	return *p.TraceIDHigh
+}
+func (p *RequestHeader) IsSetParentSpanID() bool {
+	return p.ParentSpanID != nil
+}
+func (p *RequestHeader) IsSetSampled() bool {
+	return p.Sampled != nil
+}
+func (p *RequestHeader) IsSetClientID() bool {
+	return p.ClientID != nil
+}
+func (p *RequestHeader) IsSetFlags() bool {
+	return p.Flags != nil
+}
+func (p *RequestHeader) IsSetDest() bool {
+	return p.Dest != nil
+}
+func (p *RequestHeader) IsSetDelegations() bool {
+	return p.Delegations != nil
+}
+func (p *RequestHeader) IsSetTraceIDHigh() bool {
+	return p.TraceIDHigh != nil
+}
+
+func (p *RequestHeader) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.readField1(iprot); err != nil {
+				return err
+			}
+		case 2:
+			if err := p.readField2(iprot); err != nil {
+				return err
+			}
+		case 3:
+			if err := p.readField3(iprot); err != nil {
+				return err
+			}
+		case 5:
+			if err := p.readField5(iprot); err != nil {
+				return err
+			}
+		case 6:
+			if err := p.readField6(iprot); err != nil {
+				return err
+			}
+		case 7:
+			if err := p.readField7(iprot); err != nil {
+				return err
+			}
+		case 8:
+			if err := p.readField8(iprot); err != nil {
+				return err
+			}
+		case 9:
+			if err := p.readField9(iprot); err != nil {
+				return err
+			}
+		case 10:
+			if err := p.readField10(iprot); err != nil {
+				return err
+			}
+		case 11:
+			if err := p.readField11(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.TraceID = v
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.SpanID = v
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.ParentSpanID = &v
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.Sampled = &v
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField6(iprot thrift.TProtocol) error {
+	p.ClientID = &ClientId{}
+	if err := p.ClientID.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.ClientID), err)
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 7: ", err)
+	} else {
+		p.Flags = &v
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField8(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*RequestContext, 0, size)
+	p.Contexts = tSlice
+	for i := 0; i < size; i++ {
+		_elem0 := &RequestContext{}
+		if err := _elem0.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem0), err)
+		}
+		p.Contexts = append(p.Contexts, _elem0)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField9(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 9: ", err)
+	} else {
+		p.Dest = &v
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField10(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*Delegation, 0, size)
+	p.Delegations = tSlice
+	for i := 0; i < size; i++ {
+		_elem1 := &Delegation{}
+		if err := _elem1.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem1), err)
+		}
+		p.Delegations = append(p.Delegations, _elem1)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *RequestHeader) readField11(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 11: ", err)
+	} else {
+		p.TraceIDHigh = &v
+	}
+	return nil
+}
+
+func (p *RequestHeader) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("RequestHeader"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := p.writeField1(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField2(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField3(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField5(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField6(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField7(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField8(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField9(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField10(oprot); err != nil {
+		return err
+	}
+	if err := p.writeField11(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *RequestHeader) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("trace_id", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:trace_id: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.TraceID)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.trace_id (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:trace_id: ", p), err)
+	}
+	return err
+}
+
+func (p *RequestHeader) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("span_id", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:span_id: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.SpanID)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.span_id (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:span_id: ", p), err)
+	}
+	return err
+}
+
+func (p *RequestHeader) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetParentSpanID() {
+		if err := oprot.WriteFieldBegin("parent_span_id", thrift.I64, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:parent_span_id: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.ParentSpanID)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.parent_span_id (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:parent_span_id: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *RequestHeader) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSampled() {
+		if err := oprot.WriteFieldBegin("sampled", thrift.BOOL, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:sampled: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(*p.Sampled)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.sampled (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:sampled: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *RequestHeader) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetClientID() {
+		if err := oprot.WriteFieldBegin("client_id", thrift.STRUCT, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:client_id: ", p), err)
+		}
+		if err := p.ClientID.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.ClientID), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:client_id: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *RequestHeader) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFlags() {
+		if err := oprot.WriteFieldBegin("flags", thrift.I64, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:flags: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.Flags)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.flags (7) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:flags: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *RequestHeader) writeField8(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("contexts", thrift.LIST, 8); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:contexts: ", p), err)
+	}
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Contexts)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Contexts {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 8:contexts: ", p), err)
+	}
+	return err
+}
+
+func (p *RequestHeader) writeField9(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDest() {
+		if err := oprot.WriteFieldBegin("dest", thrift.STRING, 9); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 9:dest: ", p), err)
+		}
+		if err := oprot.WriteString(string(*p.Dest)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.dest (9) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 9:dest: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *RequestHeader) writeField10(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDelegations() {
+		if err := oprot.WriteFieldBegin("delegations", thrift.LIST, 10); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 10:delegations: ", p), err)
+		}
+		if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Delegations)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
+		}
+		for _, v := range p.Delegations {
+			if err := v.Write(oprot); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+			}
+		}
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 10:delegations: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *RequestHeader) writeField11(oprot thrift.TProtocol) (err error) {
+	if p.IsSetTraceIDHigh() {
+		if err := oprot.WriteFieldBegin("trace_id_high", thrift.I64, 11); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 11:trace_id_high: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.TraceIDHigh)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.trace_id_high (11) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 11:trace_id_high: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *RequestHeader) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("RequestHeader(%+v)", *p)
+}
+
+// Attributes:
+//  - Contexts
+type ResponseHeader struct {
+	Contexts []*RequestContext `thrift:"contexts,1" json:"contexts"`
+}
+
+func NewResponseHeader() *ResponseHeader {
+	return &ResponseHeader{}
+}
+
+func (p *ResponseHeader) GetContexts() []*RequestContext {
+	return p.Contexts
+}
+func (p *ResponseHeader) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.readField1(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *ResponseHeader) readField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*RequestContext, 0, size)
+	p.Contexts = tSlice
+	for i := 0; i < size; i++ {
+		_elem2 := &RequestContext{}
+		if err := _elem2.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem2), err)
+		}
+		p.Contexts = append(p.Contexts, _elem2)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *ResponseHeader) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("ResponseHeader"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := p.writeField1(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *ResponseHeader) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("contexts", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:contexts: ", p), err)
+	}
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Contexts)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Contexts {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:contexts: ", p), err)
+	}
+	return err
+}
+
+func (p *ResponseHeader) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("ResponseHeader(%+v)", *p)
+}
+
+type ConnectionOptions struct {
+}
+
+func NewConnectionOptions() *ConnectionOptions {
+	return &ConnectionOptions{}
+}
+
+func (p *ConnectionOptions) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		if err := iprot.Skip(fieldTypeId); err != nil {
+			return err
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *ConnectionOptions) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("ConnectionOptions"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *ConnectionOptions) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("ConnectionOptions(%+v)", *p)
+}
+
+type UpgradeReply struct {
+}
+
+func NewUpgradeReply() *UpgradeReply {
+	return &UpgradeReply{}
+}
+
+func (p *UpgradeReply) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		if err := iprot.Skip(fieldTypeId); err != nil {
+			return err
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *UpgradeReply) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("UpgradeReply"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *UpgradeReply) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("UpgradeReply(%+v)", *p)
+}
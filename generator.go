@@ -6,8 +6,20 @@ import (
 	"time"
 )
 
+// kinds of IdGeneratorException, the handler reports all but errInternal as
+// the matching thrift exception
+const (
+	errInternal = iota
+	errClockMovedBackwards
+	errSequenceExhausted
+	errInvalidScope
+	errUnavailable
+)
+
 type IdGeneratorException struct {
-	message string
+	message      string
+	kind         int
+	retryAfterMs int64
 }
 
 func (exp IdGeneratorException) Error() string {
@@ -15,7 +27,11 @@ func (exp IdGeneratorException) Error() string {
 }
 
 func newException(message string) *IdGeneratorException {
-	return &IdGeneratorException{message}
+	return &IdGeneratorException{message: message}
+}
+
+func newKindException(kind int, message string) *IdGeneratorException {
+	return &IdGeneratorException{message: message, kind: kind}
 }
 
 type IdGenerator struct {
@@ -45,24 +61,42 @@ var workerIdShift uint = sequenceBits
 var datacenterIdShift uint = sequenceBits + workerIdBits
 var timestampLeftShift uint = sequenceBits + workerIdBits + datacenterIdBits
 
-func (p *IdGenerator) nextId() (r int64, err error) {
+// how long to wait for the next millisecond once the sequence is used up, the
+// clock may have stopped or been stepped back meanwhile
+var maxSequenceWait = 10 * time.Millisecond
+
+// nextId issues an id if check accepts its timestamp, which is called with the
+// lock held, after the timestamp is taken.
+func (p *IdGenerator) nextId(check func(timestamp int64) error) (r int64, err error) {
 	p.mux.Lock()
 	timestamp := getTimestamp()
 	if timestamp < p.lastTimestamp {
-		fmt.Printf("clock is moving backwards.  Rejecting requests until %d.", p.lastTimestamp)
+		warnf("clock is moving backwards.  Rejecting requests until %d.", p.lastTimestamp)
 		errMsg := fmt.Sprintf("Clock moved backwards.  Refusing to generate id for %d milliseconds", p.lastTimestamp-timestamp)
-		err := newException(errMsg)
+		err := newKindException(errClockMovedBackwards, errMsg)
+		err.retryAfterMs = p.lastTimestamp - timestamp
 		defer p.mux.Unlock()
 		return 0, err
-	} else if timestamp == p.lastTimestamp {
-		p.sequenceId = (p.sequenceId + 1) & sequenceMask
-		if p.sequenceId == 0 {
-			timestamp = tilNextMillis(p.lastTimestamp)
+	}
+	var sequenceId int64
+	if timestamp == p.lastTimestamp {
+		sequenceId = (p.sequenceId + 1) & sequenceMask
+		if sequenceId == 0 {
+			var ok bool
+			if timestamp, ok = tilNextMillis(p.lastTimestamp); !ok {
+				// keep the sequence used up, so the next call waits again
+				defer p.mux.Unlock()
+				return 0, newKindException(errSequenceExhausted,
+					fmt.Sprintf("Sequence exhausted for %s at %d and the clock did not move on", p.scope, p.lastTimestamp))
+			}
 		}
-	} else {
-		p.sequenceId = 0
+	}
+	if err := check(timestamp); err != nil {
+		defer p.mux.Unlock()
+		return 0, err
 	}
 
+	p.sequenceId = sequenceId
 	p.lastTimestamp = timestamp
 
 	id := ((timestamp - epoch) << timestampLeftShift) |
@@ -73,12 +107,16 @@ func (p *IdGenerator) nextId() (r int64, err error) {
 	return id, nil
 }
 
-func tilNextMillis(lastTimestamp int64) int64 {
+func tilNextMillis(lastTimestamp int64) (int64, bool) {
 	var timestamp = getTimestamp()
+	deadline := time.Now().Add(maxSequenceWait)
 	for timestamp <= lastTimestamp {
+		if time.Now().After(deadline) {
+			return timestamp, false
+		}
 		timestamp = getTimestamp()
 	}
-	return timestamp
+	return timestamp, true
 }
 
 func getTimestamp() int64 {
@@ -2,14 +2,32 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"unicode"
+
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
 )
 
+var maxIdsPerRequest int32 = 1000
+
+// every scope keeps a generator for the life of the server
+var maxScopes = 10000
+var maxScopeLength = 128
+
 type IdGeneratorHandler struct {
 	workerId     int64
 	datacenterId int64
 	generators   map[string]*IdGenerator
-	mux          sync.Mutex
+	closed       bool
+	// ids are only issued after notBefore and, if set, before notAfter
+	notBefore int64
+	notAfter  int64
+	// why no ids are issued for now, by cause (clock, peers, claim)
+	suspended map[string]string
+	mux       sync.Mutex
 }
 
 func NewIdGeneratorHandler(workerId int64, datacenterId int64) (handler *IdGeneratorHandler, err error) {
@@ -21,7 +39,12 @@ func NewIdGeneratorHandler(workerId int64, datacenterId int64) (handler *IdGener
 		err := newException(fmt.Sprintf("wrong data center id (must be in 0-%d)", maxDatacenterId))
 		return nil, err
 	}
-	return &IdGeneratorHandler{workerId: workerId, datacenterId: datacenterId, generators: make(map[string]*IdGenerator)}, nil
+	return &IdGeneratorHandler{
+		workerId:     workerId,
+		datacenterId: datacenterId,
+		generators:   make(map[string]*IdGenerator),
+		suspended:    make(map[string]string),
+	}, nil
 }
 
 func (p *IdGeneratorHandler) GetWorkerId() (r int64, err error) {
@@ -33,16 +56,175 @@ func (p *IdGeneratorHandler) GetTimestamp() (r int64, err error) {
 }
 
 func (p *IdGeneratorHandler) GetId(scope string) (r int64, err error) {
+	generator, err := p.generator(scope)
+	if err != nil {
+		return 0, toThriftException(err, scope)
+	}
+	id, err := generator.nextId(p.issuable)
+	if err != nil {
+		return 0, toThriftException(err, scope)
+	}
+	return id, nil
+}
+
+func (p *IdGeneratorHandler) generator(scope string) (*IdGenerator, error) {
 	p.mux.Lock()
+	defer p.mux.Unlock()
+	// fail before a new scope is taken in
+	if err := p.check(getTimestamp()); err != nil {
+		return nil, err
+	}
 	if x, found := p.generators[scope]; found {
-		p.mux.Unlock()
-		return x.nextId()
-	} else {
-		generator := newIdGenerator(p.workerId, p.datacenterId, scope)
-		p.generators[scope] = generator
-		p.mux.Unlock()
-		return generator.nextId()
+		return x, nil
+	}
+	if err := validateScope(scope); err != nil {
+		return nil, err
+	}
+	if len(p.generators) >= maxScopes {
+		return nil, newKindException(errInvalidScope, fmt.Sprintf("too many scopes (max %d)", maxScopes))
+	}
+	generator := newIdGenerator(p.workerId, p.datacenterId, scope)
+	p.generators[scope] = generator
+	return generator, nil
+}
+
+// validateScope checks a new scope. The empty scope is valid, clients of
+// IdGenerator have always used it as the default scope.
+func validateScope(scope string) error {
+	if len(scope) > maxScopeLength {
+		return newKindException(errInvalidScope, fmt.Sprintf("scope longer than %d bytes", maxScopeLength))
+	}
+	for _, c := range scope {
+		if unicode.IsSpace(c) || !unicode.IsPrint(c) {
+			return newKindException(errInvalidScope, fmt.Sprintf("scope %q contains whitespace or control characters", scope))
+		}
+	}
+	return nil
+}
+
+// toThriftException turns generator errors into the exceptions declared in
+// idgenerator.thrift, so clients can tell them apart from internal errors.
+func toThriftException(err error, scope string) error {
+	exp, ok := err.(*IdGeneratorException)
+	if !ok {
+		return err
+	}
+	switch exp.kind {
+	case errClockMovedBackwards:
+		return &idgenerator.ClockMovedBackwards{Message: exp.message, RetryAfterMs: exp.retryAfterMs}
+	case errSequenceExhausted:
+		return &idgenerator.SequenceExhausted{Message: exp.message}
+	case errInvalidScope:
+		return &idgenerator.InvalidScope{Message: exp.message, Scope: scope}
+	case errUnavailable:
+		return &idgenerator.ServiceUnavailable{Message: exp.message}
+	}
+	return err
+}
+
+// setValidity limits the timestamps ids are issued for to those of the
+// worker id lease.
+func (p *IdGeneratorHandler) setValidity(notBefore int64, notAfter int64) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.notBefore = notBefore
+	p.notAfter = notAfter
+}
+
+// unavailable returns why no ids are issued for timestamp, or "" if they
+// are. The lock must be held.
+func (p *IdGeneratorHandler) unavailable(timestamp int64) string {
+	if p.closed {
+		return "server is shutting down"
+	}
+	var reasons []string
+	for _, reason := range p.suspended {
+		reasons = append(reasons, reason)
+	}
+	if p.notAfter > 0 && timestamp >= p.notAfter {
+		reasons = append(reasons, fmt.Sprintf("lease of worker id %d expired at %d", p.workerId, p.notAfter))
 	}
+	sort.Strings(reasons)
+	return strings.Join(reasons, ", ")
+}
+
+// Unavailable returns why no ids are issued now, or "" if they are.
+func (p *IdGeneratorHandler) Unavailable() string {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.unavailable(getTimestamp())
+}
+
+// suspend stops issuing ids until resume is called with the same cause,
+// reason is sent to the clients.
+func (p *IdGeneratorHandler) suspend(cause string, reason string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.suspended[cause] = reason
+}
+
+func (p *IdGeneratorHandler) resume(cause string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	delete(p.suspended, cause)
+}
+
+// Suspended reports whether ids are held back for any cause.
+func (p *IdGeneratorHandler) Suspended() bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return len(p.suspended) > 0
+}
+
+// issuable returns an error unless an id may be issued for timestamp. The
+// generators call it for every id, under their lock, so an id is never issued
+// after the lease expired or the server was suspended or closed.
+func (p *IdGeneratorHandler) issuable(timestamp int64) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.check(timestamp)
+}
+
+// check returns an error unless timestamp is within the validity of the
+// worker id and ids are issued. The lock must be held.
+func (p *IdGeneratorHandler) check(timestamp int64) error {
+	if reason := p.unavailable(timestamp); reason != "" {
+		return newKindException(errUnavailable, reason)
+	}
+	if timestamp <= p.notBefore {
+		err := newKindException(errClockMovedBackwards, fmt.Sprintf(
+			"Worker id %d was used until %d.  Refusing to generate id for %d milliseconds",
+			p.workerId, p.notBefore, p.notBefore-timestamp+1))
+		err.retryAfterMs = p.notBefore - timestamp + 1
+		return err
+	}
+	return nil
+}
+
+// Close stops issuing ids and returns the last timestamp used, which a node
+// taking over this worker id must not go below.
+func (p *IdGeneratorHandler) Close() int64 {
+	p.mux.Lock()
+	p.closed = true
+	generators := make([]*IdGenerator, 0, len(p.generators))
+	for _, generator := range p.generators {
+		generators = append(generators, generator)
+	}
+	p.mux.Unlock()
+	// an id in progress was checked under the lock of its generator, so its
+	// timestamp is in lastTimestamp once the lock is free and no id follows
+	var lastTimestamp int64 = -1
+	for _, generator := range generators {
+		generator.mux.Lock()
+		if generator.lastTimestamp > lastTimestamp {
+			lastTimestamp = generator.lastTimestamp
+		}
+		generator.mux.Unlock()
+	}
+	if lastTimestamp > 0 {
+		infof("last issued timestamp %d", lastTimestamp)
+	}
+	return lastTimestamp
 }
 
 func (p *IdGeneratorHandler) GetDatacenterId() (r int64, err error) {
@@ -51,10 +233,68 @@ func (p *IdGeneratorHandler) GetDatacenterId() (r int64, err error) {
 
 func (p *IdGeneratorHandler) GetScopes() (r []string, err error) {
 	p.mux.Lock()
-	keys := make([]string, len(p.generators))
+	keys := make([]string, 0, len(p.generators))
 	for d := range p.generators {
 		keys = append(keys, d)
 	}
 	defer p.mux.Unlock()
 	return keys, nil
 }
+
+func (p *IdGeneratorHandler) GetIds(request *idgenerator.GetIdsRequest) (r *idgenerator.GetIdsResponse, err error) {
+	if request == nil {
+		return nil, &idgenerator.InvalidRequest{Message: "missing request"}
+	}
+	// the empty scope is only kept for the clients of IdGenerator
+	if request.Scope == "" {
+		return nil, &idgenerator.InvalidScope{Message: "scope is empty"}
+	}
+	if request.Count <= 0 || request.Count > maxIdsPerRequest {
+		return nil, &idgenerator.InvalidRequest{Message: fmt.Sprintf(
+			"wrong count %d (must be in 1-%d)", request.Count, maxIdsPerRequest)}
+	}
+	base := 0
+	switch request.Format {
+	case idgenerator.IdFormat_NUMERIC:
+	case idgenerator.IdFormat_DECIMAL:
+		base = 10
+	case idgenerator.IdFormat_HEX:
+		base = 16
+	default:
+		return nil, &idgenerator.InvalidRequest{Message: fmt.Sprintf("unknown id format %d", request.Format)}
+	}
+	generator, err := p.generator(request.Scope)
+	if err != nil {
+		return nil, toThriftException(err, request.Scope)
+	}
+	response := idgenerator.NewGetIdsResponse()
+	response.Ids = make([]int64, 0, request.Count)
+	for i := int32(0); i < request.Count; i++ {
+		if request.IsSetDeadlineMs() && getTimestamp() > request.GetDeadlineMs() {
+			return nil, &idgenerator.ServiceUnavailable{Message: fmt.Sprintf(
+				"deadline exceeded after %d of %d ids", i, request.Count)}
+		}
+		id, err := generator.nextId(p.issuable)
+		if err != nil {
+			return nil, toThriftException(err, request.Scope)
+		}
+		response.Ids = append(response.Ids, id)
+	}
+	if base != 0 {
+		response.FormattedIds = make([]string, len(response.Ids))
+		for i, id := range response.Ids {
+			response.FormattedIds[i] = strconv.FormatInt(id, base)
+		}
+	}
+	return response, nil
+}
+
+func (p *IdGeneratorHandler) GetInfo() (r *idgenerator.ServerInfo, err error) {
+	scopes, _ := p.GetScopes()
+	return &idgenerator.ServerInfo{
+		WorkerId:     p.workerId,
+		DatacenterId: p.datacenterId,
+		Timestamp:    getTimestamp(),
+		Scopes:       scopes,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+func getIdsRequest(scope string, count int32, format idgenerator.IdFormat) *idgenerator.GetIdsRequest {
+	request := idgenerator.NewGetIdsRequest()
+	request.Scope = scope
+	request.Count = count
+	request.Format = format
+	return request
+}
+
+func TestHandlerInvalidScope(t *testing.T) {
+	handler, _ := NewIdGeneratorHandler(1, 1)
+	_, err := handler.GetId("ORDER ITEM")
+	if e, ok := err.(*idgenerator.InvalidScope); !ok || e.Scope != "ORDER ITEM" || !strings.Contains(e.Message, "whitespace") {
+		t.Fatalf("getId of a scope with a space, error %#v", err)
+	}
+	_, err = handler.GetIds(getIdsRequest(strings.Repeat("x", maxScopeLength+1), 1, idgenerator.IdFormat_NUMERIC))
+	if e, ok := err.(*idgenerator.InvalidScope); !ok || len(e.Scope) != maxScopeLength+1 || !strings.Contains(e.Message, "longer than") {
+		t.Fatalf("getIds of a long scope, error %#v", err)
+	}
+	// IdGenerator has always taken the empty scope, IdGeneratorV2 does not
+	if _, err := handler.GetId(""); err != nil {
+		t.Fatalf("getId of the empty scope: %v", err)
+	}
+	_, err = handler.GetIds(getIdsRequest("", 1, idgenerator.IdFormat_NUMERIC))
+	if e, ok := err.(*idgenerator.InvalidScope); !ok || e.Message != "scope is empty" {
+		t.Fatalf("getIds of the empty scope, error %#v", err)
+	}
+}
+
+func TestHandlerInvalidRequest(t *testing.T) {
+	handler, _ := NewIdGeneratorHandler(1, 1)
+	for _, count := range []int32{0, -1, maxIdsPerRequest + 1} {
+		_, err := handler.GetIds(getIdsRequest("ORDER", count, idgenerator.IdFormat_NUMERIC))
+		if e, ok := err.(*idgenerator.InvalidRequest); !ok || !strings.Contains(e.Message, "wrong count") {
+			t.Fatalf("getIds of %d ids, error %#v", count, err)
+		}
+	}
+	_, err := handler.GetIds(getIdsRequest("ORDER", 1, idgenerator.IdFormat(99)))
+	if e, ok := err.(*idgenerator.InvalidRequest); !ok || e.Message != "unknown id format 99" {
+		t.Fatalf("getIds of an unknown format, error %#v", err)
+	}
+	if _, err := handler.GetIds(nil); err == nil {
+		t.Fatal("getIds without a request")
+	} else if _, ok := err.(*idgenerator.InvalidRequest); !ok {
+		t.Fatalf("getIds without a request, error %#v", err)
+	}
+	// no id was issued for the requests refused
+	if scopes, _ := handler.GetScopes(); len(scopes) != 0 {
+		t.Fatalf("scopes %v taken in by refused requests", scopes)
+	}
+}
+
+func TestHandlerSequenceExhausted(t *testing.T) {
+	saved := maxSequenceWait
+	maxSequenceWait = 0
+	defer func() { maxSequenceWait = saved }()
+	handler, _ := NewIdGeneratorHandler(1, 1)
+	generator, err := handler.generator("ORDER")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// use the sequence up in the current millisecond, tried again if the
+	// clock moves on before the id is asked for
+	for i := 0; i < 100; i++ {
+		generator.mux.Lock()
+		generator.lastTimestamp = getTimestamp()
+		generator.sequenceId = sequenceMask
+		generator.mux.Unlock()
+		_, err = handler.GetId("ORDER")
+		if err != nil {
+			break
+		}
+	}
+	if e, ok := err.(*idgenerator.SequenceExhausted); !ok || !strings.Contains(e.Message, "Sequence exhausted for ORDER") {
+		t.Fatalf("getId with the sequence used up, error %#v", err)
+	}
+}
+
+func TestHandlerUnavailable(t *testing.T) {
+	handler, _ := NewIdGeneratorHandler(1, 1)
+	handler.suspend("clock", "clock is 12s ahead")
+	_, err := handler.GetId("ORDER")
+	if e, ok := err.(*idgenerator.ServiceUnavailable); !ok || e.Message != "clock is 12s ahead" {
+		t.Fatalf("getId while suspended, error %#v", err)
+	}
+	_, err = handler.GetIds(getIdsRequest("ORDER", 10, idgenerator.IdFormat_NUMERIC))
+	if e, ok := err.(*idgenerator.ServiceUnavailable); !ok || e.Message != "clock is 12s ahead" {
+		t.Fatalf("getIds while suspended, error %#v", err)
+	}
+	handler.resume("clock")
+
+	// ids of the previous holder of the worker id reach into the future
+	handler.setValidity(getTimestamp()+1000, 0)
+	_, err = handler.GetId("ORDER")
+	if e, ok := err.(*idgenerator.ClockMovedBackwards); !ok || e.RetryAfterMs <= 0 || e.RetryAfterMs > 1001 {
+		t.Fatalf("getId before the worker id may be used, error %#v", err)
+	}
+}
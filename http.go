@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+type HTTPOptions struct {
+	// thrift over http is served on this port, 0 disables it
+	Port int    `yaml:"port"`
+	Path string `yaml:"path"`
+	// origins browsers may call from, "*" for any
+	AllowedOrigins stringList `yaml:"allowedOrigins"`
+}
+
+const (
+	contentTypeThrift = "application/x-thrift"
+	contentTypeJSON   = "application/vnd.apache.thrift.json"
+)
+
+// thriftHTTPHandler serves one thrift call per POST, like THttpServer, but
+// detects the protocol of each request the same way as the thrift port does.
+type thriftHTTPHandler struct {
+	processor       thrift.TProcessor
+	protocolFactory *sniffProtocolFactory
+	options         HTTPOptions
+	maxRequestSize  int64
+}
+
+func newThriftHTTPServer(processor thrift.TProcessor, options HTTPOptions, serverOptions ServerOptions, tlsConfig *tls.Config) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(options.Path, &thriftHTTPHandler{
+		processor:       processor,
+		protocolFactory: newSniffProtocolFactory(),
+		options:         options,
+		maxRequestSize:  int64(serverOptions.MaxFrameSize),
+	})
+	return &http.Server{
+		Addr:        fmt.Sprintf(":%d", options.Port),
+		Handler:     mux,
+		ReadTimeout: serverOptions.ReadTimeout,
+		IdleTimeout: serverOptions.IdleTimeout,
+		TLSConfig:   tlsConfig,
+	}
+}
+
+func serveHTTP(server *http.Server) error {
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+	if server.TLSConfig != nil {
+		listener = tls.NewListener(listener, server.TLSConfig)
+	}
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (p *thriftHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.allowOrigin(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST, OPTIONS")
+		http.Error(w, "thrift requests must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, p.maxRequestSize))
+	protocol := protocolBinary
+	if header, err := body.Peek(1); err != nil {
+		http.Error(w, "empty request", http.StatusBadRequest)
+		return
+	} else if detected, ok := protocolOf(header[0]); ok {
+		protocol = detected
+	}
+	connectionCounters.Add("http-"+protocol, 1)
+
+	in := &detectedTransport{TTransport: thrift.NewStreamTransportR(body), protocol: protocol}
+	buffer := thrift.NewTMemoryBuffer()
+	out := &detectedTransport{TTransport: buffer, protocol: protocol}
+	ok, err := p.processor.Process(p.protocolFactory.GetProtocol(in), p.protocolFactory.GetProtocol(out))
+	if !ok && buffer.Len() == 0 {
+		debugf("bad http request from %s: %v", r.RemoteAddr, err)
+		http.Error(w, fmt.Sprint("cannot process request: ", err), http.StatusBadRequest)
+		return
+	}
+	if protocol == protocolJSON {
+		w.Header().Set("Content-Type", contentTypeJSON)
+	} else {
+		w.Header().Set("Content-Type", contentTypeThrift)
+	}
+	w.Write(buffer.Bytes())
+}
+
+func (p *thriftHTTPHandler) allowOrigin(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	for _, allowed := range p.options.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Add("Vary", "Origin")
+			return
+		}
+	}
+}
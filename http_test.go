@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+// TestThriftHTTPClient calls the http port the way idgenerator-cli -u does.
+func TestThriftHTTPClient(t *testing.T) {
+	handler, _ := NewIdGeneratorHandler(1, 1)
+	options := HTTPOptions{Path: "/thrift"}
+	server := httptest.NewServer(newThriftHTTPServer(newProcessor(handler), options, defaultConfig().Server, nil).Handler)
+	defer server.Close()
+
+	protocols := map[string]thrift.TProtocolFactory{
+		"binary":  thrift.NewTBinaryProtocolFactoryDefault(),
+		"compact": thrift.NewTCompactProtocolFactory(),
+		"json":    thrift.NewTJSONProtocolFactory(),
+	}
+	for name, protocolFactory := range protocols {
+		trans, err := thrift.NewTHttpPostClientWithOptions(server.URL+"/thrift", thrift.THttpClientOptions{Client: &http.Client{Timeout: time.Second}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := trans.Open(); err != nil {
+			t.Fatal(err)
+		}
+		client := idgenerator.NewIdGeneratorClientFactory(trans, protocolFactory)
+		iprot := protocolFactory.GetProtocol(trans)
+		clientV2 := idgenerator.NewIdGeneratorV2ClientProtocol(trans, iprot, thrift.NewTMultiplexedProtocol(iprot, "IdGeneratorV2"))
+
+		id, err := client.GetId("ORDER")
+		if err != nil {
+			t.Fatalf("%s getId: %v", name, err)
+		}
+		if id <= 0 {
+			t.Fatalf("%s getId returned %d", name, id)
+		}
+		request := idgenerator.NewGetIdsRequest()
+		request.Scope = "ORDER"
+		request.Count = 3
+		response, err := clientV2.GetIds(request)
+		if err != nil {
+			t.Fatalf("%s getIds: %v", name, err)
+		}
+		if len(response.Ids) != 3 || response.Ids[0] <= id {
+			t.Fatalf("%s getIds returned %v after %d", name, response.Ids, id)
+		}
+		trans.Close()
+	}
+}
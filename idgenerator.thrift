@@ -1,10 +1,77 @@
 namespace go idgenerator
 namespace java idgenerator
 
+// The clock is behind the last timestamp used, retry after retryAfterMs or
+// on another server.
+exception ClockMovedBackwards {
+  1: string message
+  2: i64 retryAfterMs
+}
+
+// All sequence numbers of the current millisecond are used and the clock did
+// not move on in time.
+exception SequenceExhausted {
+  1: string message
+}
+
+exception InvalidScope {
+  1: string message
+  2: string scope
+}
+
+// The server does not issue ids at the moment, e.g. while shutting down.
+exception ServiceUnavailable {
+  1: string message
+}
+
+// The request is malformed, e.g. a count out of range or an unknown format.
+exception InvalidRequest {
+  1: string message
+}
+
 service IdGenerator {
   i64 getWorkerId()
   i64 getTimestamp()
-  i64 getId(1:string scope)
+  i64 getId(1:string scope) throws (1:ClockMovedBackwards clockMovedBackwards, 2:SequenceExhausted sequenceExhausted,
+                                    3:InvalidScope invalidScope, 4:ServiceUnavailable serviceUnavailable)
   i64 getDatacenterId()
   list<string> getScopes()
 }
+
+// IdGeneratorV2 is served multiplexed (service name "IdGeneratorV2") next to
+// IdGenerator on the same port. Add fields with new ids only, so old clients
+// keep working.
+
+enum IdFormat {
+  NUMERIC = 0,
+  DECIMAL = 1,
+  HEX = 2
+}
+
+struct GetIdsRequest {
+  1: string scope
+  2: i32 count = 1
+  3: IdFormat format = IdFormat.NUMERIC
+  // unix time in milliseconds after which the server gives up
+  4: optional i64 deadlineMs
+}
+
+struct GetIdsResponse {
+  1: list<i64> ids
+  // filled for DECIMAL and HEX formats
+  2: list<string> formattedIds
+}
+
+struct ServerInfo {
+  1: i64 workerId
+  2: i64 datacenterId
+  3: i64 timestamp
+  4: list<string> scopes
+}
+
+service IdGeneratorV2 {
+  GetIdsResponse getIds(1:GetIdsRequest request) throws (1:ClockMovedBackwards clockMovedBackwards,
+      2:SequenceExhausted sequenceExhausted, 3:InvalidScope invalidScope, 4:ServiceUnavailable serviceUnavailable,
+      5:InvalidRequest invalidRequest)
+  ServerInfo getInfo()
+}
@@ -0,0 +1,172 @@
+// Package serverset reads and watches the members of a serverset in
+// zookeeper, as finagle and go.serversets register them, for the registry of
+// the server and the cluster of the client.
+package serverset
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// statuses of a member, only ALIVE members are called
+const (
+	StatusAlive   = "ALIVE"
+	StatusWarning = "WARNING"
+)
+
+// Member is the data of a member znode. Finagle ignores metadata.
+type Member struct {
+	ServiceEndpoint     Endpoint            `json:"serviceEndpoint"`
+	AdditionalEndpoints map[string]Endpoint `json:"additionalEndpoints"`
+	Status              string              `json:"status"`
+	Shard               int                 `json:"shard"`
+	Metadata            map[string]string   `json:"metadata,omitempty"`
+}
+
+// Addr returns the address (host:port) of the service endpoint.
+func (m Member) Addr() string {
+	return net.JoinHostPort(m.ServiceEndpoint.Host, strconv.Itoa(m.ServiceEndpoint.Port))
+}
+
+type Endpoint struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+// Members reads the addresses (host:port) of the ALIVE members of the
+// serverset at dir, sorted. Invalid member data is reported to logger and
+// left out.
+func Members(conn *zk.Conn, dir string, logger zk.Logger) ([]string, error) {
+	return members(conn, dir, logger, nil)
+}
+
+// Watch follows the ALIVE members of a serverset.
+type Watch struct {
+	conn   *zk.Conn
+	dir    string
+	logger zk.Logger
+	// paths of the znodes watched, zk watches fire once and setting one
+	// again on the same znode adds another
+	watching map[string]bool
+	changed  chan string
+	closed   <-chan bool
+}
+
+// NewWatch watches the serverset at dir until closed is closed.
+func NewWatch(conn *zk.Conn, dir string, logger zk.Logger, closed <-chan bool) *Watch {
+	return &Watch{conn: conn, dir: dir, logger: logger, watching: make(map[string]bool), changed: make(chan string), closed: closed}
+}
+
+// Members reads the members as the function Members does and sets the zk
+// watches missing.
+func (w *Watch) Members() ([]string, error) {
+	return members(w.conn, w.dir, w.logger, w)
+}
+
+// Run calls update with the members whenever they change, starting with
+// addrs, until the watch is closed. Members are read again every second
+// while zookeeper cannot be read.
+func (w *Watch) Run(addrs []string, update func([]string)) {
+	for {
+		select {
+		case path := <-w.changed:
+			delete(w.watching, path)
+		case <-w.closed:
+			return
+		}
+		next, err := w.Members()
+		if err != nil {
+			w.logger.Printf("cannot read serverset %s: %v", w.dir, err)
+			// try again with the next change or after a second
+			go func() {
+				select {
+				case <-time.After(time.Second):
+				case <-w.closed:
+					return
+				}
+				select {
+				case w.changed <- "":
+				case <-w.closed:
+				}
+			}()
+			continue
+		}
+		// a member going away fires the watches of the serverset and of the
+		// member
+		if reflect.DeepEqual(next, addrs) {
+			continue
+		}
+		addrs = next
+		update(addrs)
+	}
+}
+
+func (w *Watch) add(path string, event <-chan zk.Event) {
+	w.watching[path] = true
+	go func() {
+		select {
+		case <-event:
+			select {
+			case w.changed <- path:
+			case <-w.closed:
+			}
+		case <-w.closed:
+		}
+	}()
+}
+
+// members reads the ALIVE members and, with a watch, sets the zk watches
+// missing.
+func members(conn *zk.Conn, dir string, logger zk.Logger, watch *Watch) ([]string, error) {
+	var children []string
+	var err error
+	if watch != nil && !watch.watching[dir] {
+		var event <-chan zk.Event
+		if children, _, event, err = conn.ChildrenW(dir); err == nil {
+			watch.add(dir, event)
+		}
+	} else {
+		children, _, err = conn.Children(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	addrs := []string{}
+	for _, child := range children {
+		if !strings.HasPrefix(child, "member_") {
+			continue
+		}
+		path := dir + "/" + child
+		var data []byte
+		if watch != nil && !watch.watching[path] {
+			var event <-chan zk.Event
+			if data, _, event, err = conn.GetW(path); err == nil {
+				watch.add(path, event)
+			}
+		} else {
+			data, _, err = conn.Get(path)
+		}
+		if err == zk.ErrNoNode {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var member Member
+		if err := json.Unmarshal(data, &member); err != nil {
+			logger.Printf("invalid member %s: %v", path, err)
+			continue
+		}
+		if member.Status == StatusAlive {
+			addrs = append(addrs, member.Addr())
+		}
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
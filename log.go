@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	levelDebug int32 = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+var logLevel = levelInfo
+var logger = log.New(os.Stdout, "", log.LstdFlags)
+var logFile io.Closer
+var logMux sync.Mutex
+
+func parseLogLevel(name string) (int32, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return int32(level), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q (must be one of %s)", name, strings.Join(levelNames, ", "))
+}
+
+func setLogLevel(name string) error {
+	level, err := parseLogLevel(name)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32(&logLevel, level)
+	return nil
+}
+
+// setLogFile (re)opens the log file, so sending SIGHUP after rotating it makes
+// the server write to the new file. An empty name logs to stdout.
+func setLogFile(name string) error {
+	var out io.Writer = os.Stdout
+	var closer io.Closer
+	if name != "" {
+		file, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		out = file
+		closer = file
+	}
+	logMux.Lock()
+	defer logMux.Unlock()
+	logger.SetOutput(out)
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = closer
+	return nil
+}
+
+func logf(level int32, format string, args ...interface{}) {
+	if level < atomic.LoadInt32(&logLevel) {
+		return
+	}
+	logger.Output(3, strings.ToUpper(levelNames[level])+" "+fmt.Sprintf(format, args...))
+}
+
+func debugf(format string, args ...interface{}) {
+	logf(levelDebug, format, args...)
+}
+
+func infof(format string, args ...interface{}) {
+	logf(levelInfo, format, args...)
+}
+
+func warnf(format string, args ...interface{}) {
+	logf(levelWarn, format, args...)
+}
+
+func errorf(format string, args ...interface{}) {
+	logf(levelError, format, args...)
+}
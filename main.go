@@ -1,17 +1,16 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"math"
 	"net"
+	"net/http"
 	"os"
 	"strconv"
-	"strings"
 
 	"git.apache.org/thrift.git/lib/go/thrift"
-	"github.com/liusf/idgenerator/gen-go/idgenerator"
-	"github.com/strava/go.serversets"
 )
 
 func Usage() {
@@ -22,147 +21,314 @@ func Usage() {
 
 func main() {
 	flag.Usage = Usage
-	port := flag.Int("p", 0, "port to listen to")
+	configFile := flag.String("config", os.Getenv("IDGENERATOR_CONFIG"), "yaml config file, reloaded on SIGHUP")
 	help := flag.Bool("h", false, "show this help info")
-	workerId := flag.Int("w", 0, "worker id (0-15)")
-	datacenterId := flag.Int("dc", 0, "data center id (0-7)")
-	zkServers := flag.String("zk", "", "check and register with zookeepers(ip:port,ip:port,..)")
+	defaultConfig().bindFlags(flag.CommandLine)
 
 	flag.Parse()
-	if *port <= 0 || *help {
+	if *help {
 		Usage()
 		os.Exit(1)
 	}
+	config, err := loadConfig(*configFile, flag.CommandLine)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid config:", err)
+		Usage()
+		os.Exit(1)
+	}
+	setLogLevel(config.Logging.Level)
+	if err := setLogFile(config.Logging.File); err != nil {
+		fmt.Fprintln(os.Stderr, "cannot open log file:", err)
+		os.Exit(1)
+	}
+
+	var certs *certReloader
+	if config.TLS.Enabled() {
+		certs, err = newCertReloader(config.TLS)
+		if err != nil {
+			errorf("error loading tls certificates: %v", err)
+			os.Exit(1)
+		}
+		go certs.watch()
+	}
 
-	if *zkServers != "" {
-		serversets.BaseDirectory = "/service"
-		serversets.BaseZnodePath = func(environment serversets.Environment, service string) string {
-			return serversets.BaseDirectory + "/" + service
+	workerId := config.WorkerId
+	var registry Registry
+	var claim *workerClaim
+	var coordinator *coordinator
+	var host string
+	if kind, servers, _ := config.registry(); kind != "" {
+		host, err = advertiseHost(config.Advertise)
+		if err != nil {
+			errorf("%v", err)
+			os.Exit(1)
+		}
+		registry, err = newRegistry(kind, servers, config.BasePath)
+		if err != nil {
+			errorf("unable to connect to %s servers %v %v", kind, servers, err)
+			os.Exit(1)
+		}
+		if config.Coordinator.Enabled {
+			coordinator = newCoordinator(registry, config, host, certs)
 		}
-		addrs, serverSet := getPeerAddrs(*zkServers)
-		sanityCheck(int64(*workerId), int64(*datacenterId), addrs)
-		registerService(int(*port), serverSet)
-		fmt.Println("Sanity check OK")
+		claim, err = joinCluster(registry, coordinator, config, host, certs)
+		if err != nil {
+			errorf("%v", err)
+			os.Exit(1)
+		}
+		workerId = claim.workerId
 	}
 
-	transport, err := thrift.NewTServerSocket(fmt.Sprintf("0.0.0.0:%d", *port))
+	var transport thrift.TServerTransport
+	if certs != nil {
+		transport, err = thrift.NewTSSLServerSocket(fmt.Sprintf(":%d", config.Port), certs.ServerConfig())
+	} else {
+		transport, err = thrift.NewTServerSocket(fmt.Sprintf(":%d", config.Port))
+	}
 	if err != nil {
-		fmt.Println("error open addr", err)
+		errorf("error open addr %v", err)
 		return
 	}
 
-	handler, err := NewIdGeneratorHandler(int64(*workerId), int64(*datacenterId))
+	handler, err := NewIdGeneratorHandler(workerId, config.DatacenterId)
 	if err != nil {
-		fmt.Println("error starting server: ", err)
+		errorf("error starting server: %v", err)
 		os.Exit(1)
 	}
-	protocolFactory := thrift.NewTBinaryProtocolFactoryDefault()
-	transportFactory := thrift.NewTFramedTransportFactory(thrift.NewTTransportFactory())
-	processor := idgenerator.NewIdGeneratorProcessor(handler)
-	server := thrift.NewTSimpleServer4(processor, transport, transportFactory, protocolFactory)
-	err = server.Serve()
-	if err != nil {
-		fmt.Println("error running server: ", err)
-		os.Exit(1)
-	} else {
-		fmt.Println("running id generator server")
+	if claim != nil {
+		handler.setValidity(claim.notBefore, claim.leaseExpiry)
+		go claim.keepAlive(handler)
+		go func() {
+			<-claim.Lost()
+			if err := registry.Deregister(); err != nil {
+				warnf("cannot deregister: %v", err)
+			} else {
+				infof("deregistered from the registry")
+			}
+		}()
 	}
-}
-
-func getPeerAddrs(zkServers string) ([]string, *serversets.ServerSet) {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("Recovered in watch", r)
+	processor := newProcessor(handler)
+	server := NewIdGeneratorServer(newTTwitterProcessorFactory(processor), transport, config.Server)
+	go func() {
+		if err := server.Serve(); err != nil {
+			errorf("error running server: %v", err)
+			os.Exit(1)
 		}
 	}()
-	serverSet := serversets.New(serversets.Production, "idgenerators", strings.Split(zkServers, ","))
-	watch, err := serverSet.Watch()
-	if err != nil {
-		fmt.Println("unable to connect to zk servers", zkServers, err)
-		os.Exit(1)
+	var httpServer *http.Server
+	if config.HTTP.Port > 0 {
+		var httpTLSConfig *tls.Config
+		if certs != nil {
+			httpTLSConfig = certs.ServerConfig()
+		}
+		httpServer = newThriftHTTPServer(processor, config.HTTP, config.Server, httpTLSConfig)
+		go func() {
+			if err := serveHTTP(httpServer); err != nil {
+				errorf("error running http server: %v", err)
+				os.Exit(1)
+			}
+		}()
+		infof("serving thrift over http on port %d path %s", config.HTTP.Port, config.HTTP.Path)
 	}
-	defer watch.Close()
-	endpoints := watch.Endpoints()
-	fmt.Println("endpoints = ", endpoints)
-	return endpoints, serverSet
+	if config.Admin.Port > 0 {
+		kind, _, _ := config.registry()
+		adminServer := newAdminServer(config.Admin, handler, registry, claim, coordinator, kind)
+		if certs != nil {
+			adminServer.TLSConfig = certs.ServerConfig()
+		}
+		go func() {
+			if err := serveHTTP(adminServer); err != nil {
+				errorf("error running admin server: %v", err)
+				os.Exit(1)
+			}
+		}()
+		infof("serving /health, /status and /debug/vars on port %d", config.Admin.Port)
+	}
+	reloader := newConfigReloader(*configFile, flag.CommandLine, config, server, certs)
+	go reloader.watch()
+	if coordinator != nil {
+		go coordinator.run(handler, reloader)
+	} else if registry != nil {
+		go checkPeers(registry, handler, workerId, host, certs, reloader)
+		go monitorClock(registry, handler, host, certs, reloader)
+	}
+	infof("running id generator server")
+	waitForShutdown(server, httpServer, registry, claim, handler, reloader)
 }
 
-func sanityCheck(workerId int64, datacenterId int64, addrs []string) {
-	// check peers, no duplicated datacenterId & workerId, no too much time shift
-	if addrs == nil {
-		fmt.Println("Unable to resolve peers address", addrs)
-		os.Exit(1)
+// joinCluster takes the worker id, checks it and the clock against the peers
+// and registers this node. With a coordinator the worker id is assigned by it
+// and the clock is checked against its state, unless we are the coordinator.
+func joinCluster(registry Registry, coordinator *coordinator, config *Config, host string, certs *certReloader) (*workerClaim, error) {
+	workerId := config.WorkerId
+	if config.AutoWorkerId {
+		workerId = -1
 	}
-	if len(addrs) == 0 {
-		fmt.Println("No peers")
-		return
+	var peers []*peerClock
+	var addrs []string
+	var err error
+	_, static := registry.(*staticRegistry)
+	if coordinator != nil {
+		if workerId, peers, err = coordinator.join(config); err != nil {
+			return nil, err
+		}
+	} else {
+		if addrs, err = registry.Peers(); err != nil {
+			return nil, fmt.Errorf("unable to list peers: %v", err)
+		}
+		addrs = otherPeers(addrs, host, config.Port)
+		infof("endpoints = %v", addrs)
+		// a worker id given is checked before its lease is written, an
+		// automatic one is only known after the claim, which skips the ids
+		// other nodes hold leases of
+		if !config.AutoWorkerId {
+			if err := sanityCheck(workerId, config.DatacenterId, addrs, certs, config.SanityCheck, static); err != nil {
+				return nil, err
+			}
+		}
 	}
-	var sumTimestamp int64 = 0
-	for _, addr := range addrs {
-		pair := strings.Split(addr, ":")
-		port, err := strconv.Atoi(pair[1])
-		if err != nil {
-			fmt.Println("port error")
-			continue
+	owner := net.JoinHostPort(host, strconv.Itoa(config.Port))
+	claim, err := registry.ClaimWorkerId(config.DatacenterId, workerId, config.AutoWorkerId, owner, config.WorkerLease)
+	if err != nil {
+		return nil, fmt.Errorf("cannot claim worker id: %v", err)
+	}
+	if config.AutoWorkerId {
+		infof("claimed worker id %d", claim.workerId)
+	}
+	if peers != nil {
+		debugf("clock offsets by the coordinator: %s", describeOffsets(peers))
+		if err := checkClock(peers, config.DatacenterId, config.SanityCheck); err != nil {
+			claim.Release(-1)
+			return nil, fmt.Errorf("Clock sanity check failed: %v", err)
 		}
-		timestamp, peerDatacenterId, peerWorkerId := newIdGeneratorClient(pair[0], port)
-		if datacenterId != peerDatacenterId {
-			fmt.Printf("Worker at %s has datacenter_id %d, but ours is %d", addr, peerDatacenterId, datacenterId)
-			os.Exit(1)
-		} else if workerId == peerWorkerId {
-			fmt.Println("Duplicated workerId", workerId)
-			os.Exit(1)
-		} else {
-			sumTimestamp += timestamp
+	} else if config.AutoWorkerId {
+		if err := sanityCheck(claim.workerId, config.DatacenterId, addrs, certs, config.SanityCheck, static); err != nil {
+			claim.Release(-1)
+			return nil, err
 		}
 	}
-	avg := sumTimestamp / int64(len(addrs))
-	if math.Abs(float64(avg-getTimestamp())) > 10000.0 {
-		fmt.Printf("Timestamp sanity check failed. Mean timestamp is %d, but mine is %d, "+
-			"so I'm more than 10s away from the mean", avg, getTimestamp())
-		os.Exit(1)
+	member := newServersetMember(host, config.Port, config.DatacenterId, claim.workerId)
+	if member.AdditionalEndpoints, err = additionalEndpoints(config, host); err != nil {
+		claim.Release(-1)
+		return nil, err
 	}
+	if err := registry.Register(member); err != nil {
+		claim.Release(-1)
+		return nil, fmt.Errorf("cannot register endpoint %v", err)
+	}
+	infof("Sanity check OK")
+	return claim, nil
 }
 
-func registerService(port int, serverSet *serversets.ServerSet) {
-	host := getLocalIp()
-	_, err := serverSet.RegisterEndpoint(host, port, nil)
+// checkPeers looks for peers with our worker id whenever the peers change.
+// While there are any no ids are issued and the node is WARNING, like with
+// the clock off. Those peers are WARNING too if they check as well, which
+// leaves them out of the registered peers, so they are asked again until
+// they are gone or have another worker id.
+func checkPeers(registry Registry, handler *IdGeneratorHandler, workerId int64, host string, certs *certReloader, config *configReloader) {
+	peers, err := registry.Watch()
 	if err != nil {
-		fmt.Println("cannot register endpoint", err)
-		os.Exit(1)
+		warnf("cannot watch peers: %v", err)
+		return
+	}
+	guard := newCheckGuard("peers", registry, handler)
+	// checked by joinCluster
+	<-peers
+	var duplicates []string
+	for addrs := range peers {
+		options := config.Config()
+		addrs = otherPeers(addrs, host, options.Port)
+		for _, addr := range duplicates {
+			if !containsString(addrs, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+		found, unreachable := queryPeers(addrs, certs, options.SanityCheck)
+		if len(unreachable) > 0 {
+			warnf("skipped unreachable peers %v, %d of %d answered", unreachable, len(found), len(addrs))
+		}
+		duplicates = duplicatePeers(workerId, options.DatacenterId, found)
+		if len(duplicates) > 0 {
+			guard.update(fmt.Errorf("Duplicated workerId %d in datacenter %d, also used by %v", workerId, options.DatacenterId, duplicates))
+		} else {
+			guard.update(nil)
+			infof("no peer of %v has worker id %d", addrs, workerId)
+		}
 	}
 }
 
-func getLocalIp() string {
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		fmt.Println("cannot get local IP[1]", err)
-		os.Exit(1)
+// duplicatePeers returns the addresses of the peers with our worker id.
+func duplicatePeers(workerId int64, datacenterId int64, peers []*peerClock) []string {
+	var addrs []string
+	for _, peer := range peers {
+		if datacenterId == peer.datacenterId && workerId == peer.workerId {
+			addrs = append(addrs, peer.addr)
+		}
 	}
-	for _, i := range ifaces {
-		addrs, err := i.Addrs()
-		// handle err
-		if err != nil {
-			fmt.Println("cannot get local IP[2]", err)
-			os.Exit(1)
+	return addrs
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
 		}
-		for _, addr := range addrs {
-			var ip net.IP
-			switch v := addr.(type) {
-			case *net.IPNet:
-				ip = v.IP
-			case *net.IPAddr:
-				ip = v.IP
+	}
+	return false
+}
+
+// otherPeers leaves this node out of addrs, a static list of peers usually
+// names every node.
+func otherPeers(addrs []string, host string, port int) []string {
+	var others []string
+	for _, addr := range addrs {
+		peerHost, peerPort, err := net.SplitHostPort(addr)
+		if err == nil && peerPort == strconv.Itoa(port) {
+			if peerHost == host || peerHost == "localhost" {
+				continue
 			}
-			if strings.HasPrefix(ip.String(), "172.") ||
-				strings.HasPrefix(ip.String(), "192.168.") ||
-				strings.HasPrefix(ip.String(), "10.") {
-				fmt.Println("register local IP ", ip.String())
-				return ip.String()
+			if ip := net.ParseIP(peerHost); ip != nil && ip.IsLoopback() {
+				continue
 			}
 		}
+		others = append(others, addr)
+	}
+	return others
+}
+
+// sanityCheck fails if fewer peers than the quorum answer, or any peer does
+// not in strict mode, unless skipDown is set for static peer lists which name
+// nodes not started yet.
+func sanityCheck(workerId int64, datacenterId int64, addrs []string, certs *certReloader, options SanityCheckOptions, skipDown bool) error {
+	// check peers, no duplicated datacenterId & workerId, no too much time shift
+	// within and across datacenters
+	if len(addrs) == 0 {
+		infof("No peers")
+		return nil
+	}
+	peers, unreachable := queryPeers(addrs, certs, options)
+	if len(unreachable) > 0 && !skipDown {
+		if options.Strict {
+			return fmt.Errorf("Could not talk to peers %v", unreachable)
+		}
+		needed := int(math.Ceil(options.Quorum * float64(len(addrs))))
+		if len(peers) < needed {
+			return fmt.Errorf("Only %d of %d peers answered, %d needed. Unreachable: %v", len(peers), len(addrs), needed, unreachable)
+		}
+	}
+	if len(unreachable) > 0 {
+		warnf("skipped unreachable peers %v, %d of %d answered", unreachable, len(peers), len(addrs))
+	}
+	if duplicates := duplicatePeers(workerId, datacenterId, peers); len(duplicates) > 0 {
+		return fmt.Errorf("Duplicated workerId %d in datacenter %d, also used by %s", workerId, datacenterId, duplicates[0])
+	}
+	if len(peers) == 0 {
+		infof("No peers reachable")
+		return nil
+	}
+	debugf("peer clock offsets: %s", describeOffsets(peers))
+	if err := checkClock(peers, datacenterId, options); err != nil {
+		return fmt.Errorf("Clock sanity check failed: %v", err)
 	}
-	fmt.Println("cannot get local IP[3]")
-	os.Exit(1)
-	return ""
+	return nil
 }
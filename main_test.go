@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testConfig(port int) *Config {
+	config := defaultConfig()
+	config.Port = port
+	config.DatacenterId = 1
+	config.AutoWorkerId = true
+	config.WorkerLease = time.Minute
+	// the peers registered by the test do not listen
+	config.SanityCheck.Quorum = 0
+	config.SanityCheck.PeerTimeout = 100 * time.Millisecond
+	return config
+}
+
+func TestJoinCluster(t *testing.T) {
+	r1 := newMemoryRegistry("/test")
+	c1, err := joinCluster(r1, nil, testConfig(3456), "127.0.0.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2 := r1.join()
+	c2, err := joinCluster(r2, nil, testConfig(3457), "127.0.0.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1.workerId != 0 || c2.workerId != 1 {
+		t.Fatalf("joined with worker ids %d and %d, expected 0 and 1", c1.workerId, c2.workerId)
+	}
+	peers, _ := r1.Peers()
+	if expected := []string{"127.0.0.1:3456", "127.0.0.1:3457"}; !reflect.DeepEqual(peers, expected) {
+		t.Fatalf("peers %v, expected %v", peers, expected)
+	}
+
+	// a worker id given which another node holds the lease of
+	config := testConfig(3458)
+	config.AutoWorkerId = false
+	config.WorkerId = 0
+	r3 := r1.join()
+	if _, err := joinCluster(r3, nil, config, "127.0.0.1", nil); err == nil || !strings.Contains(err.Error(), "is leased by 127.0.0.1:3456") {
+		t.Fatalf("joined with the worker id of a live lease, error %v", err)
+	}
+	if r3.Registered() {
+		t.Fatal("registered after failing to join")
+	}
+	if lease := readLease(t, r3, c1.leasePath); lease.Owner != "127.0.0.1:3456" || lease.Epoch != 1 {
+		t.Fatalf("lease %+v was written by a node failing to join", lease)
+	}
+
+	// released by a node shutting down, another one takes it over
+	r1.Deregister()
+	c1.Release(getTimestamp())
+	r1.Close()
+	c3, err := joinCluster(r3, nil, config, "127.0.0.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c3.workerId != 0 || c3.Epoch() != 2 {
+		t.Fatalf("joined with worker id %d epoch %d, expected 0 epoch 2", c3.workerId, c3.Epoch())
+	}
+}
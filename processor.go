@@ -0,0 +1,105 @@
+package main
+
+import (
+	"expvar"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+// per method counters, keyed by service.method
+var (
+	requestCounters      = expvar.NewMap("requests")
+	requestErrorCounters = expvar.NewMap("request_errors")
+	requestMicros        = expvar.NewMap("request_micros")
+)
+
+// processorFunctions is implemented by the generated processors.
+type processorFunctions interface {
+	GetProcessorFunction(key string) (thrift.TProcessorFunction, bool)
+}
+
+// serviceProcessor dispatches to the functions of a generated processor, so
+// the generated code needs no changes. Unlike the generated Process it keeps
+// the connection open after an unknown method, which finagle clients call to
+// probe for the tracing protocol, and it recovers from panics in handlers.
+type serviceProcessor struct {
+	service   string
+	functions processorFunctions
+}
+
+func newServiceProcessor(service string, functions processorFunctions) *serviceProcessor {
+	return &serviceProcessor{service: service, functions: functions}
+}
+
+// newProcessor serves IdGenerator and IdGeneratorV2 multiplexed, and
+// IdGenerator also to un-multiplexed calls from existing clients.
+func newProcessor(handler *IdGeneratorHandler) thrift.TProcessor {
+	processor := thrift.NewTMultiplexedProcessor()
+	legacyProcessor := newServiceProcessor("IdGenerator", idgenerator.NewIdGeneratorProcessor(handler))
+	processor.RegisterProcessor("IdGenerator", legacyProcessor)
+	processor.RegisterProcessor("IdGeneratorV2", newServiceProcessor("IdGeneratorV2", idgenerator.NewIdGeneratorV2Processor(handler)))
+	processor.RegisterDefault(legacyProcessor)
+	return processor
+}
+
+func (p *serviceProcessor) Process(iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+	name, _, seqId, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return false, err
+	}
+	function, ok := p.functions.GetProcessorFunction(name)
+	if !ok {
+		requestCounters.Add(p.service+".unknown", 1)
+		debugf("unknown method %s.%s", p.service, name)
+		if err := iprot.Skip(thrift.STRUCT); err != nil {
+			return false, err
+		}
+		if err := iprot.ReadMessageEnd(); err != nil {
+			return false, err
+		}
+		if err := writeApplicationException(oprot, name, seqId,
+			thrift.UNKNOWN_METHOD, "Unknown function "+name); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	key := p.service + "." + name
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		requestCounters.Add(key, 1)
+		requestMicros.Add(key, int64(elapsed/time.Microsecond))
+		if r := recover(); r != nil {
+			errorf("panic in %s%s: %v\n%s", key, traceOf(oprot), r, debug.Stack())
+			writeApplicationException(oprot, name, seqId,
+				thrift.INTERNAL_ERROR, fmt.Sprint("Internal error processing ", name))
+			// the reply may have been written halfway, don't reuse the connection
+			success = false
+			err = thrift.NewTApplicationException(thrift.INTERNAL_ERROR, fmt.Sprint(r))
+		}
+		if err != nil {
+			requestErrorCounters.Add(key, 1)
+		}
+		debugf("%s took %v%s", key, elapsed, traceOf(oprot))
+	}()
+	return function.Process(seqId, iprot, oprot)
+}
+
+func writeApplicationException(oprot thrift.TProtocol, name string, seqId int32, typeId int32, message string) thrift.TException {
+	x := thrift.NewTApplicationException(typeId, message)
+	if err := oprot.WriteMessageBegin(name, thrift.EXCEPTION, seqId); err != nil {
+		return err
+	}
+	if err := x.Write(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return err
+	}
+	return oprot.Flush()
+}
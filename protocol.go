@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"expvar"
+	"sync"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// Clients are not configured consistently (finagle and the java clients use
+// framed binary, the generated remote tool defaults to unframed), so the
+// transport and protocol are detected from the first bytes of each connection.
+
+const (
+	protocolBinary  = "binary"
+	protocolCompact = "compact"
+	protocolJSON    = "json"
+)
+
+var connectionCounters = expvar.NewMap("connections")
+
+type sniffTransport struct {
+	thrift.TTransport
+	reader   *bufio.Reader
+	once     sync.Once
+	detected *detectedTransport
+}
+
+func newSniffTransport(client thrift.TTransport) *sniffTransport {
+	return &sniffTransport{TTransport: client, reader: bufio.NewReader(client)}
+}
+
+func (p *sniffTransport) Read(buf []byte) (int, error) {
+	return p.reader.Read(buf)
+}
+
+func (p *sniffTransport) RemainingBytes() uint64 {
+	remaining := p.TTransport.RemainingBytes()
+	if remaining == ^uint64(0) {
+		return remaining
+	}
+	return uint64(p.reader.Buffered()) + remaining
+}
+
+// detect blocks until the first bytes arrived and returns the same transport
+// for the input and output side of the connection.
+func (p *sniffTransport) detect(maxFrameSize uint32) *detectedTransport {
+	p.once.Do(func() {
+		framed, protocol := p.sniff()
+		var transport thrift.TTransport
+		name := protocol
+		if framed {
+			transport = thrift.NewTFramedTransportMaxLength(p, maxFrameSize)
+			name = "framed-" + protocol
+		} else {
+			transport = thrift.NewTBufferedTransport(p, 8192)
+		}
+		connectionCounters.Add(name, 1)
+		p.detected = &detectedTransport{TTransport: transport, protocol: protocol}
+	})
+	return p.detected
+}
+
+func (p *sniffTransport) sniff() (framed bool, protocol string) {
+	if header, err := p.reader.Peek(1); err == nil {
+		if protocol, ok := protocolOf(header[0]); ok {
+			return false, protocol
+		}
+	}
+	header, err := p.reader.Peek(5)
+	if err != nil {
+		// let the binary protocol report the broken connection
+		return false, protocolBinary
+	}
+	if protocol, ok := protocolOf(header[4]); ok {
+		return true, protocol
+	}
+	if header[4] == 0 {
+		// non-strict binary: frame size followed by the method name length
+		return true, protocolBinary
+	}
+	// non-strict unframed binary starts with the method name length
+	return false, protocolBinary
+}
+
+func protocolOf(b byte) (string, bool) {
+	switch b {
+	case 0x80:
+		return protocolBinary, true
+	case 0x82:
+		return protocolCompact, true
+	case '[':
+		return protocolJSON, true
+	}
+	return "", false
+}
+
+type detectedTransport struct {
+	thrift.TTransport
+	protocol string
+}
+
+type sniffProtocolFactory struct {
+	binary  thrift.TProtocolFactory
+	compact thrift.TProtocolFactory
+	json    thrift.TProtocolFactory
+}
+
+func newSniffProtocolFactory() *sniffProtocolFactory {
+	return &sniffProtocolFactory{
+		binary:  thrift.NewTBinaryProtocolFactoryDefault(),
+		compact: thrift.NewTCompactProtocolFactory(),
+		json:    thrift.NewTJSONProtocolFactory(),
+	}
+}
+
+func (p *sniffProtocolFactory) GetProtocol(trans thrift.TTransport) thrift.TProtocol {
+	if detected, ok := trans.(*detectedTransport); ok {
+		switch detected.protocol {
+		case protocolCompact:
+			return p.compact.GetProtocol(trans)
+		case protocolJSON:
+			return p.json.GetProtocol(trans)
+		}
+	}
+	return p.binary.GetProtocol(trans)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+	"github.com/liusf/idgenerator/gen-go/tracing"
+)
+
+// startServer serves the id generator on a free port of the loopback
+// interface and returns its address.
+func startServer(t *testing.T, options ServerOptions) (*IdGeneratorServer, string) {
+	transport, err := thrift.NewTServerSocket("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transport.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	handler, _ := NewIdGeneratorHandler(1, 1)
+	server := NewIdGeneratorServer(newTTwitterProcessorFactory(newProcessor(handler)), transport, options)
+	go server.Serve()
+	t.Cleanup(func() { server.Shutdown(time.Second) })
+	return server, transport.Addr().String()
+}
+
+// callBytes returns a getId call as a client writes it.
+func callBytes(protocolFactory thrift.TProtocolFactory, framed bool, name string, args thrift.TStruct) []byte {
+	buffer := thrift.NewTMemoryBuffer()
+	protocol := protocolFactory.GetProtocol(buffer)
+	protocol.WriteMessageBegin(name, thrift.CALL, 1)
+	args.Write(protocol)
+	protocol.WriteMessageEnd()
+	protocol.Flush()
+	data := buffer.Bytes()
+	if !framed {
+		return data
+	}
+	frame := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	return append(frame, data...)
+}
+
+func getIdArgs() thrift.TStruct {
+	args := idgenerator.NewIdGeneratorGetIdArgs()
+	args.Scope = "ORDER"
+	return args
+}
+
+func TestSniffTransport(t *testing.T) {
+	binaryStrict := thrift.NewTBinaryProtocolFactoryDefault()
+	binaryLoose := thrift.NewTBinaryProtocolFactory(false, false)
+	compact := thrift.NewTCompactProtocolFactory()
+	json := thrift.NewTJSONProtocolFactory()
+	framedBinary := callBytes(binaryStrict, true, "getId", getIdArgs())
+	tests := []struct {
+		name     string
+		chunks   [][]byte
+		framed   bool
+		protocol string
+	}{
+		{"framed binary", [][]byte{framedBinary}, true, protocolBinary},
+		{"framed non-strict binary", [][]byte{callBytes(binaryLoose, true, "getId", getIdArgs())}, true, protocolBinary},
+		{"unframed binary", [][]byte{callBytes(binaryStrict, false, "getId", getIdArgs())}, false, protocolBinary},
+		{"unframed non-strict binary", [][]byte{callBytes(binaryLoose, false, "getId", getIdArgs())}, false, protocolBinary},
+		{"framed compact", [][]byte{callBytes(compact, true, "getId", getIdArgs())}, true, protocolCompact},
+		{"unframed compact", [][]byte{callBytes(compact, false, "getId", getIdArgs())}, false, protocolCompact},
+		{"framed json", [][]byte{callBytes(json, true, "getId", getIdArgs())}, true, protocolJSON},
+		{"unframed json", [][]byte{callBytes(json, false, "getId", getIdArgs())}, false, protocolJSON},
+		{"ttwitter upgrade", [][]byte{callBytes(binaryStrict, true, finagleUpgradeMethod, tracing.NewConnectionOptions())}, true, protocolBinary},
+		// neither is thrift, read as unframed binary which fails on the
+		// message
+		{"http", [][]byte{[]byte("POST /thrift HTTP/1.1\r\nHost: localhost\r\n\r\n")}, false, protocolBinary},
+		{"garbage", [][]byte{{0xff, 0xfe, 0xfd, 0xfc, 0xfb, 0xfa}}, false, protocolBinary},
+		// the frame size arrives in pieces
+		{"partial first read", [][]byte{framedBinary[:1], framedBinary[1:3], framedBinary[3:]}, true, protocolBinary},
+		{"short", [][]byte{framedBinary[:3]}, false, protocolBinary},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer listener.Close()
+			go func() {
+				conn, err := net.Dial("tcp", listener.Addr().String())
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				for _, chunk := range test.chunks {
+					conn.Write(chunk)
+					time.Sleep(20 * time.Millisecond)
+				}
+				// the short header is all there is
+				conn.(*net.TCPConn).CloseWrite()
+				io.Copy(io.Discard, conn)
+			}()
+			conn, err := listener.Accept()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			detected := newSniffTransport(thrift.NewTSocketFromConnTimeout(conn, 0)).detect(16384000)
+			_, framed := detected.TTransport.(*thrift.TFramedTransport)
+			if framed != test.framed || detected.protocol != test.protocol {
+				t.Fatalf("detected framed %v %s, expected framed %v %s", framed, detected.protocol, test.framed, test.protocol)
+			}
+		})
+	}
+}
+
+func TestServerProtocols(t *testing.T) {
+	_, addr := startServer(t, defaultConfig().Server)
+	protocols := map[string]thrift.TProtocolFactory{
+		protocolBinary:  thrift.NewTBinaryProtocolFactoryDefault(),
+		protocolCompact: thrift.NewTCompactProtocolFactory(),
+		protocolJSON:    thrift.NewTJSONProtocolFactory(),
+	}
+	for name, protocolFactory := range protocols {
+		for _, framed := range []bool{true, false} {
+			socket, err := thrift.NewTSocketTimeout(addr, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var trans thrift.TTransport = socket
+			if framed {
+				trans = thrift.NewTFramedTransport(socket)
+			}
+			if err := trans.Open(); err != nil {
+				t.Fatal(err)
+			}
+			client := idgenerator.NewIdGeneratorClientFactory(trans, protocolFactory)
+			// twice, the connection stays with the protocol detected
+			for i := 0; i < 2; i++ {
+				if id, err := client.GetId("ORDER"); err != nil || id <= 0 {
+					t.Fatalf("getId over %s framed %v returned %d: %v", name, framed, id, err)
+				}
+			}
+			trans.Close()
+		}
+	}
+}
+
+func TestServerTTwitterUpgrade(t *testing.T) {
+	_, addr := startServer(t, defaultConfig().Server)
+	socket, err := thrift.NewTSocketTimeout(addr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trans := thrift.NewTFramedTransport(socket)
+	if err := trans.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer trans.Close()
+	protocol := thrift.NewTBinaryProtocolFactoryDefault().GetProtocol(trans)
+
+	protocol.WriteMessageBegin(finagleUpgradeMethod, thrift.CALL, 1)
+	tracing.NewConnectionOptions().Write(protocol)
+	protocol.WriteMessageEnd()
+	if err := protocol.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	name, typeId, _, err := protocol.ReadMessageBegin()
+	if err != nil || name != finagleUpgradeMethod || typeId != thrift.REPLY {
+		t.Fatalf("upgrade answered %s %d: %v", name, typeId, err)
+	}
+	if err := tracing.NewUpgradeReply().Read(protocol); err != nil {
+		t.Fatal(err)
+	}
+	protocol.ReadMessageEnd()
+
+	// every call is now preceded by a header, and so is every reply
+	tracing.NewRequestHeader().Write(protocol)
+	protocol.WriteMessageBegin("getId", thrift.CALL, 2)
+	getIdArgs().Write(protocol)
+	protocol.WriteMessageEnd()
+	if err := protocol.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracing.NewResponseHeader().Read(protocol); err != nil {
+		t.Fatal(err)
+	}
+	if name, _, _, err := protocol.ReadMessageBegin(); err != nil || name != "getId" {
+		t.Fatalf("getId answered %s: %v", name, err)
+	}
+	result := idgenerator.NewIdGeneratorGetIdResult()
+	if err := result.Read(protocol); err != nil {
+		t.Fatal(err)
+	}
+	if result.Success == nil || *result.Success <= 0 {
+		t.Fatalf("getId after the upgrade returned %+v", result)
+	}
+}
+
+// closedWithin fails unless the server closes conn within timeout, and
+// returns how long it took.
+func closedWithin(t *testing.T, conn net.Conn, timeout time.Duration) time.Duration {
+	t.Helper()
+	start := time.Now()
+	conn.SetReadDeadline(start.Add(timeout))
+	// closed with unread input, the server resets the connection
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		if e, ok := err.(net.Error); ok && e.Timeout() {
+			t.Fatalf("connection not closed within %v", timeout)
+		}
+	}
+	return time.Since(start)
+}
+
+// TestServerClosesGarbage checks that input which is not thrift closes the
+// connection instead of leaving it waiting.
+func TestServerClosesGarbage(t *testing.T) {
+	_, addr := startServer(t, defaultConfig().Server)
+	for _, input := range []string{"GET / HTTP/1.1\r\nHost: localhost\r\n\r\n", "\xff\xfe\xfd\xfc\xfb\xfa"} {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Write([]byte(input))
+		closedWithin(t, conn, 5*time.Second)
+		conn.Close()
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/liusf/idgenerator/internal/serverset"
+)
+
+// Registry is where the nodes of a cluster find each other and coordinate
+// their worker ids. Its nodes keep the worker id claims and the coordinator.
+type Registry interface {
+	workerStore
+	// Register announces this node to the others, member is ALIVE.
+	Register(member serversetMember) error
+	Deregister() error
+	// SetStatus changes the serverset status of the registered node, nodes
+	// not ALIVE are left out of Peers and Watch.
+	SetStatus(status string) error
+	// Registered reports whether the registration is in place, it is not
+	// after the session was lost until the node registered again.
+	Registered() bool
+	// Peers returns the addresses (host:port) of the registered nodes.
+	Peers() ([]string, error)
+	// Watch sends the addresses of the registered nodes now and whenever they
+	// change, until the registry is closed.
+	Watch() (<-chan []string, error)
+	// ClaimWorkerId takes the lease of workerId in the datacenter. With auto
+	// the worker id is marked in use for the session, a negative workerId
+	// takes the lowest free one.
+	ClaimWorkerId(datacenterId int64, workerId int64, auto bool, owner string, lease time.Duration) (*workerClaim, error)
+	// Close ends the session, which removes the registration and worker id
+	// claims left.
+	Close()
+}
+
+// serverset statuses of a node, it is WARNING while it does not issue ids
+const (
+	statusAlive   = serverset.StatusAlive
+	statusWarning = serverset.StatusWarning
+)
+
+// serverset member data, with the ids of the node in metadata. shard is
+// always 0, as go.serversets wrote it.
+type serversetMember = serverset.Member
+
+type serversetEndpoint = serverset.Endpoint
+
+func newServersetMember(host string, port int, datacenterId int64, workerId int64) serversetMember {
+	return serversetMember{
+		ServiceEndpoint:     serversetEndpoint{Host: host, Port: port},
+		AdditionalEndpoints: map[string]serversetEndpoint{},
+		Status:              statusAlive,
+		Metadata: map[string]string{
+			"datacenterId": strconv.FormatInt(datacenterId, 10),
+			"workerId":     strconv.FormatInt(workerId, 10),
+		},
+	}
+}
+
+// newRegistry connects to the registry of kind zk, etcd, memory, static or
+// file.
+func newRegistry(kind string, servers []string, basePath string) (Registry, error) {
+	switch kind {
+	case "zk":
+		return newZkRegistry(servers, basePath)
+	case "etcd":
+		return newEtcdRegistry(servers, basePath)
+	case "memory":
+		return newMemoryRegistry(basePath), nil
+	case "static":
+		return newStaticRegistry(servers, "", basePath)
+	case "file":
+		return newStaticRegistry(nil, servers[0], basePath)
+	}
+	return nil, fmt.Errorf("unknown registry %s", kind)
+}
+
+// takeWorkerId claims workerId, or a free worker id of the datacenter if it is
+// negative, with auto and leases workerId otherwise.
+func takeWorkerId(store workerStore, basePath string, datacenterId int64, workerId int64, auto bool, owner string, lease time.Duration) (*workerClaim, error) {
+	if auto {
+		return claimWorkerId(store, basePath, datacenterId, workerId, owner, lease)
+	}
+	return leaseWorkerId(store, basePath, datacenterId, workerId, owner, lease)
+}
+
+// memoryRegistry keeps the nodes in the process. Registries made with join
+// share them, each with its own session, so tests can start a cluster without
+// zookeeper and a single node can run with worker id leases.
+type memoryRegistry struct {
+	nodes    *memoryNodes
+	basePath string
+	session  int64
+	member   string
+}
+
+type memoryNodes struct {
+	nodes       map[string]*memoryNode
+	watches     map[int64][]chan []string
+	lastSession int64
+	lastVersion int64
+	mux         sync.Mutex
+}
+
+type memoryNode struct {
+	data    []byte
+	version int64
+	// owning session of an ephemeral node
+	session int64
+	// serverset status of a member
+	status string
+}
+
+func newMemoryRegistry(basePath string) *memoryRegistry {
+	nodes := &memoryNodes{nodes: make(map[string]*memoryNode), watches: make(map[int64][]chan []string)}
+	return nodes.newSession(basePath)
+}
+
+// join returns a registry for another node of the same cluster.
+func (r *memoryRegistry) join() *memoryRegistry {
+	return r.nodes.newSession(r.basePath)
+}
+
+func (n *memoryNodes) newSession(basePath string) *memoryRegistry {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	n.lastSession++
+	return &memoryRegistry{nodes: n, basePath: basePath, session: n.lastSession}
+}
+
+func (r *memoryRegistry) membersPrefix() string {
+	return r.basePath + "/idgenerators/member_"
+}
+
+func (r *memoryRegistry) Register(member serversetMember) error {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	if r.member != "" {
+		return errNodeExists
+	}
+	r.nodes.lastVersion++
+	r.member = fmt.Sprintf("%s%016x", r.membersPrefix(), r.session)
+	r.nodes.nodes[r.member] = &memoryNode{
+		data:    []byte(member.Addr()),
+		version: r.nodes.lastVersion,
+		session: r.session,
+		status:  statusAlive,
+	}
+	r.notify()
+	return nil
+}
+
+func (r *memoryRegistry) Deregister() error {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	if r.member == "" {
+		return nil
+	}
+	delete(r.nodes.nodes, r.member)
+	r.member = ""
+	r.notify()
+	return nil
+}
+
+func (r *memoryRegistry) SetStatus(status string) error {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	if r.member == "" {
+		return nil
+	}
+	r.nodes.nodes[r.member].status = status
+	r.notify()
+	return nil
+}
+
+func (r *memoryRegistry) Registered() bool {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	return r.member != ""
+}
+
+func (r *memoryRegistry) Peers() ([]string, error) {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	return r.peers(), nil
+}
+
+func (r *memoryRegistry) peers() []string {
+	addrs := []string{}
+	for path, node := range r.nodes.nodes {
+		if strings.HasPrefix(path, r.membersPrefix()) && node.status == statusAlive {
+			addrs = append(addrs, string(node.data))
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+func (r *memoryRegistry) Watch() (<-chan []string, error) {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	watch := make(chan []string, 1)
+	watch <- r.peers()
+	r.nodes.watches[r.session] = append(r.nodes.watches[r.session], watch)
+	return watch, nil
+}
+
+// notify replaces the addresses not received yet by the watches.
+func (r *memoryRegistry) notify() {
+	peers := r.peers()
+	for _, watches := range r.nodes.watches {
+		for _, watch := range watches {
+			select {
+			case <-watch:
+			default:
+			}
+			watch <- peers
+		}
+	}
+}
+
+func (r *memoryRegistry) ClaimWorkerId(datacenterId int64, workerId int64, auto bool, owner string, lease time.Duration) (*workerClaim, error) {
+	return takeWorkerId(r, r.basePath, datacenterId, workerId, auto, owner, lease)
+}
+
+func (r *memoryRegistry) Close() {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	for path, node := range r.nodes.nodes {
+		if node.session == r.session {
+			delete(r.nodes.nodes, path)
+		}
+	}
+	r.member = ""
+	for _, watch := range r.nodes.watches[r.session] {
+		close(watch)
+	}
+	delete(r.nodes.watches, r.session)
+	r.notify()
+}
+
+// the workerStore methods
+
+func (r *memoryRegistry) createEphemeral(path string, data []byte) error {
+	return r.createNode(path, data, r.session)
+}
+
+func (r *memoryRegistry) create(path string, data []byte) error {
+	return r.createNode(path, data, 0)
+}
+
+func (r *memoryRegistry) createNode(path string, data []byte, session int64) error {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	if _, found := r.nodes.nodes[path]; found {
+		return errNodeExists
+	}
+	r.nodes.lastVersion++
+	r.nodes.nodes[path] = &memoryNode{data: data, version: r.nodes.lastVersion, session: session}
+	return nil
+}
+
+func (r *memoryRegistry) get(path string) ([]byte, int64, error) {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	node, found := r.nodes.nodes[path]
+	if !found {
+		return nil, 0, errNoNode
+	}
+	return node.data, node.version, nil
+}
+
+func (r *memoryRegistry) set(path string, data []byte, version int64) (int64, error) {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	node, found := r.nodes.nodes[path]
+	if !found {
+		return 0, errNoNode
+	}
+	if node.version != version {
+		return 0, errBadVersion
+	}
+	r.nodes.lastVersion++
+	node.data = data
+	node.version = r.nodes.lastVersion
+	return node.version, nil
+}
+
+func (r *memoryRegistry) delete(path string) error {
+	r.nodes.mux.Lock()
+	defer r.nodes.mux.Unlock()
+	if _, found := r.nodes.nodes[path]; !found {
+		return errNoNode
+	}
+	delete(r.nodes.nodes, path)
+	return nil
+}
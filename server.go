@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+type ServerOptions struct {
+	// connections above this are closed right after accept
+	MaxConnections int `yaml:"maxConnections"`
+	// how long a connection may wait between requests
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+	// how long reading a request may take once its first byte arrived
+	ReadTimeout time.Duration `yaml:"readTimeout"`
+	// largest request, framed or not, in bytes
+	MaxFrameSize int `yaml:"maxFrameSize"`
+}
+
+// IdGeneratorServer replaces thrift's TSimpleServer, which starts a goroutine
+// for every connection without any limit.
+type IdGeneratorServer struct {
+	processorFactory thrift.TProcessorFactory
+	serverTransport  thrift.TServerTransport
+	protocolFactory  thrift.TProtocolFactory
+	options          ServerOptions
+	open             int
+	quit             chan struct{}
+	stopOnce         sync.Once
+	active           map[*limitTransport]struct{}
+	stopping         bool
+	wg               sync.WaitGroup
+	mux              sync.Mutex
+}
+
+func NewIdGeneratorServer(processorFactory thrift.TProcessorFactory, serverTransport thrift.TServerTransport, options ServerOptions) *IdGeneratorServer {
+	return &IdGeneratorServer{
+		processorFactory: processorFactory,
+		serverTransport:  serverTransport,
+		protocolFactory:  newSniffProtocolFactory(),
+		options:          options,
+		quit:             make(chan struct{}),
+		active:           make(map[*limitTransport]struct{}),
+	}
+}
+
+func (p *IdGeneratorServer) Serve() error {
+	if err := p.serverTransport.Listen(); err != nil {
+		return err
+	}
+	for {
+		client, err := p.serverTransport.Accept()
+		if err != nil {
+			select {
+			case <-p.quit:
+				return nil
+			default:
+			}
+			warnf("error accepting connection: %v", err)
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		if client == nil {
+			continue
+		}
+		p.mux.Lock()
+		options := p.options
+		accepted := p.open < options.MaxConnections
+		if accepted {
+			p.open++
+			p.wg.Add(1)
+		}
+		p.mux.Unlock()
+		if accepted {
+			go p.handle(client, options)
+		} else {
+			connectionCounters.Add("rejected", 1)
+			warnf("too many connections (max %d), closing connection from %s",
+				options.MaxConnections, remoteAddr(client))
+			client.Close()
+		}
+	}
+}
+
+// SetOptions changes the limits for connections accepted from now on.
+func (p *IdGeneratorServer) SetOptions(options ServerOptions) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.options = options
+}
+
+// Stop closes the listener. Connections already accepted are kept open.
+func (p *IdGeneratorServer) Stop() error {
+	var err error
+	p.stopOnce.Do(func() {
+		close(p.quit)
+		err = p.serverTransport.Interrupt()
+	})
+	return err
+}
+
+// Shutdown stops accepting connections, closes idle ones and lets requests in
+// progress finish. Connections still busy after timeout are closed, in which
+// case it returns false.
+func (p *IdGeneratorServer) Shutdown(timeout time.Duration) bool {
+	p.Stop()
+	p.mux.Lock()
+	p.stopping = true
+	for conn := range p.active {
+		conn.closeIfIdle()
+	}
+	p.mux.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+	}
+	p.mux.Lock()
+	for conn := range p.active {
+		conn.Close()
+	}
+	p.mux.Unlock()
+	return false
+}
+
+func (p *IdGeneratorServer) handle(client thrift.TTransport, options ServerOptions) {
+	defer p.wg.Done()
+	defer client.Close()
+
+	limited := newLimitTransport(client, options)
+	p.mux.Lock()
+	if p.stopping {
+		p.open--
+		p.mux.Unlock()
+		return
+	}
+	p.active[limited] = struct{}{}
+	p.mux.Unlock()
+	defer func() {
+		p.mux.Lock()
+		delete(p.active, limited)
+		p.open--
+		p.mux.Unlock()
+	}()
+
+	if !limited.startRequest() {
+		return
+	}
+	trans := newSniffTransport(limited).detect(uint32(options.MaxFrameSize))
+	processor := p.processorFactory.GetProcessor(trans)
+	iprot := p.protocolFactory.GetProtocol(trans)
+	oprot := p.protocolFactory.GetProtocol(trans)
+	for {
+		ok, err := processor.Process(iprot, oprot)
+		if !ok {
+			if err != nil && !isClosed(err) && !limited.isClosing() {
+				infof("closing connection from %s: %v", remoteAddr(client), err)
+			}
+			return
+		}
+		if !limited.startRequest() {
+			return
+		}
+	}
+}
+
+func remoteAddr(trans thrift.TTransport) string {
+	if conn := connOf(trans); conn != nil {
+		return conn.RemoteAddr().String()
+	}
+	return "unknown"
+}
+
+func connOf(trans thrift.TTransport) net.Conn {
+	if c, ok := trans.(interface {
+		Conn() net.Conn
+	}); ok {
+		return c.Conn()
+	}
+	return nil
+}
+
+func isClosed(err error) bool {
+	if e, ok := err.(thrift.TTransportException); ok && e.TypeId() == thrift.END_OF_FILE {
+		return true
+	}
+	return err == io.EOF || err.Error() == io.EOF.Error()
+}
+
+// limitTransport enforces the idle and read timeouts and the request size.
+// It reads from the connection directly because TSocket resets the deadline on
+// every read.
+type limitTransport struct {
+	thrift.TTransport
+	conn    net.Conn
+	options ServerOptions
+	read    uint64
+	waiting bool
+	closing bool
+	mux     sync.Mutex
+}
+
+func newLimitTransport(client thrift.TTransport, options ServerOptions) *limitTransport {
+	return &limitTransport{TTransport: client, conn: connOf(client), options: options}
+}
+
+// startRequest returns false once the connection should be closed instead of
+// waiting for another request.
+func (p *limitTransport) startRequest() bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.closing {
+		return false
+	}
+	p.read = 0
+	p.waiting = true
+	p.setReadTimeout(p.options.IdleTimeout)
+	return true
+}
+
+// closeIfIdle makes a connection waiting for its next request return from
+// Read, and lets a connection in the middle of a request finish it first.
+func (p *limitTransport) closeIfIdle() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.closing = true
+	if p.waiting && p.conn != nil {
+		p.conn.SetReadDeadline(time.Now())
+	}
+}
+
+func (p *limitTransport) isClosing() bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.closing
+}
+
+func (p *limitTransport) setReadTimeout(timeout time.Duration) {
+	if p.conn == nil {
+		return
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	p.conn.SetReadDeadline(deadline)
+}
+
+func (p *limitTransport) Read(buf []byte) (int, error) {
+	if p.options.MaxFrameSize > 0 {
+		remaining := p.RemainingBytes()
+		if remaining == 0 {
+			return 0, thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION,
+				fmt.Sprintf("request larger than %d bytes", p.options.MaxFrameSize))
+		}
+		if uint64(len(buf)) > remaining {
+			buf = buf[:remaining]
+		}
+	}
+	var n int
+	var err error
+	if p.conn != nil {
+		n, err = p.conn.Read(buf)
+		if err != nil {
+			err = thrift.NewTTransportExceptionFromError(err)
+		}
+	} else {
+		n, err = p.TTransport.Read(buf)
+	}
+	p.mux.Lock()
+	p.read += uint64(n)
+	if p.waiting && n > 0 {
+		p.waiting = false
+		p.setReadTimeout(p.options.ReadTimeout)
+	}
+	p.mux.Unlock()
+	return n, err
+}
+
+func (p *limitTransport) RemainingBytes() uint64 {
+	if p.options.MaxFrameSize == 0 {
+		return ^uint64(0)
+	}
+	if p.read >= uint64(p.options.MaxFrameSize) {
+		return 0
+	}
+	return uint64(p.options.MaxFrameSize) - p.read
+}
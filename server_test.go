@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+func getId(t *testing.T, addr string) (thrift.TTransport, *idgenerator.IdGeneratorClient) {
+	t.Helper()
+	socket, err := thrift.NewTSocketTimeout(addr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trans := thrift.NewTFramedTransport(socket)
+	if err := trans.Open(); err != nil {
+		t.Fatal(err)
+	}
+	client := idgenerator.NewIdGeneratorClientFactory(trans, thrift.NewTBinaryProtocolFactoryDefault())
+	if _, err := client.GetId("ORDER"); err != nil {
+		t.Fatalf("getId: %v", err)
+	}
+	return trans, client
+}
+
+func TestServerMaxConnections(t *testing.T) {
+	options := defaultConfig().Server
+	options.MaxConnections = 2
+	_, addr := startServer(t, options)
+	first, _ := getId(t, addr)
+	second, _ := getId(t, addr)
+	defer second.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	closedWithin(t, conn, time.Second)
+
+	// a closed connection frees its slot
+	first.Close()
+	waitFor(t, "a free connection slot", func() bool {
+		socket, _ := thrift.NewTSocketTimeout(addr, time.Second)
+		trans := thrift.NewTFramedTransport(socket)
+		if trans.Open() != nil {
+			return false
+		}
+		defer trans.Close()
+		_, err := idgenerator.NewIdGeneratorClientFactory(trans, thrift.NewTBinaryProtocolFactoryDefault()).GetId("ORDER")
+		return err == nil
+	})
+}
+
+func TestServerMaxFrameSize(t *testing.T) {
+	options := defaultConfig().Server
+	options.MaxFrameSize = 1024
+	_, addr := startServer(t, options)
+
+	// a frame header announcing more than allowed
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, 1<<20)
+	conn.Write(append(header, 0x80, 0x01, 0x00, 0x01))
+	closedWithin(t, conn, time.Second)
+
+	// an unframed request growing past the limit
+	conn, err = net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	args := idgenerator.NewIdGeneratorGetIdArgs()
+	args.Scope = string(make([]byte, 2048))
+	conn.Write(callBytes(thrift.NewTBinaryProtocolFactoryDefault(), false, "getId", args))
+	closedWithin(t, conn, time.Second)
+}
+
+func TestServerIdleTimeout(t *testing.T) {
+	options := defaultConfig().Server
+	options.IdleTimeout = 100 * time.Millisecond
+	options.ReadTimeout = time.Minute
+	_, addr := startServer(t, options)
+
+	// never sends a request
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if took := closedWithin(t, conn, 5*time.Second); took < 50*time.Millisecond {
+		t.Fatalf("idle connection closed after %v, expected the idle timeout", took)
+	}
+
+	// idle after a request
+	trans, client := getId(t, addr)
+	defer trans.Close()
+	time.Sleep(300 * time.Millisecond)
+	if _, err := client.GetId("ORDER"); err == nil {
+		t.Fatal("connection idle for longer than the idle timeout still open")
+	}
+}
+
+func TestServerReadTimeout(t *testing.T) {
+	options := defaultConfig().Server
+	options.IdleTimeout = time.Minute
+	options.ReadTimeout = 100 * time.Millisecond
+	_, addr := startServer(t, options)
+
+	// a request which stops after its first bytes
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	request := callBytes(thrift.NewTBinaryProtocolFactoryDefault(), true, "getId", getIdArgs())
+	conn.Write(request[:8])
+	if took := closedWithin(t, conn, 5*time.Second); took < 50*time.Millisecond {
+		t.Fatalf("slow request closed after %v, expected the read timeout", took)
+	}
+}
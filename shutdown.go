@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+type ShutdownOptions struct {
+	// time for clients to notice the deregistration before the port closes
+	Grace time.Duration `yaml:"grace"`
+	// how long requests in progress may take to finish
+	DrainTimeout time.Duration `yaml:"drainTimeout"`
+}
+
+// waitForShutdown blocks until SIGTERM or SIGINT, then takes the node out of
+// the registry before it stops accepting connections, so a rolling deploy is
+// not visible to clients. The worker id lease is released last. A second
+// signal exits immediately. The timeouts are taken from the config in effect
+// when the signal arrives.
+func waitForShutdown(server *IdGeneratorServer, httpServer *http.Server, registry Registry, claim *workerClaim, handler *IdGeneratorHandler, config *configReloader) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-signals
+	infof("received %v, shutting down", sig)
+	options := config.Config().Shutdown
+	go func() {
+		sig := <-signals
+		warnf("received %v again, exiting now", sig)
+		os.Exit(1)
+	}()
+
+	if registry != nil {
+		if err := registry.Deregister(); err != nil {
+			warnf("cannot deregister: %v", err)
+		} else {
+			infof("deregistered from the registry")
+		}
+		time.Sleep(options.Grace)
+	}
+	httpDrained := make(chan bool, 1)
+	go func() {
+		if httpServer == nil {
+			httpDrained <- true
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), options.DrainTimeout)
+		defer cancel()
+		drained := httpServer.Shutdown(ctx) == nil
+		if !drained {
+			httpServer.Close()
+		}
+		httpDrained <- drained
+	}()
+	drained := server.Shutdown(options.DrainTimeout)
+	if <-httpDrained && drained {
+		infof("all connections drained")
+	} else {
+		warnf("drain timeout, closed remaining connections")
+	}
+	infof("connections served by protocol: %s", connectionCounters)
+	lastTimestamp := handler.Close()
+	if claim != nil {
+		// after the handler stopped issuing ids with it
+		claim.Release(lastTimestamp)
+	}
+	if registry != nil {
+		registry.Close()
+	}
+	os.Exit(0)
+}
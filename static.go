@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// how often the peers file is checked for changes
+var peersFileInterval = 10 * time.Second
+
+// staticRegistry takes the peers from a list or from a file of host:port, one
+// or more per line, which is read again when it changes. Nothing is
+// registered, the list has to name every node, and worker ids are leased in
+// the process only, the sanity check against the peers is what keeps them
+// apart.
+type staticRegistry struct {
+	*memoryRegistry
+	peers  []string
+	file   string
+	closed chan bool
+}
+
+func newStaticRegistry(peers []string, file string, basePath string) (*staticRegistry, error) {
+	r := &staticRegistry{memoryRegistry: newMemoryRegistry(basePath), peers: peers, file: file, closed: make(chan bool)}
+	if _, err := r.Peers(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *staticRegistry) Register(member serversetMember) error {
+	return nil
+}
+
+func (r *staticRegistry) Deregister() error {
+	return nil
+}
+
+func (r *staticRegistry) SetStatus(status string) error {
+	return nil
+}
+
+func (r *staticRegistry) Registered() bool {
+	return true
+}
+
+func (r *staticRegistry) Peers() ([]string, error) {
+	if r.file == "" {
+		return append([]string{}, r.peers...), nil
+	}
+	return readPeersFile(r.file)
+}
+
+// readPeersFile reads host:port lists separated by commas or newlines, lines
+// starting with # are comments.
+func readPeersFile(file string) ([]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	peers := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, addr := range splitList(line) {
+			if !strings.Contains(addr, ":") {
+				return nil, fmt.Errorf("invalid peer %q in %s, expected host:port", addr, file)
+			}
+			peers = append(peers, addr)
+		}
+	}
+	return peers, nil
+}
+
+func (r *staticRegistry) Watch() (<-chan []string, error) {
+	addrs, err := r.Peers()
+	if err != nil {
+		return nil, err
+	}
+	peers := make(chan []string, 1)
+	peers <- addrs
+	if r.file == "" {
+		return peers, nil
+	}
+	go func() {
+		defer close(peers)
+		var modTime time.Time
+		if info, err := os.Stat(r.file); err == nil {
+			modTime = info.ModTime()
+		}
+		for {
+			select {
+			case <-time.After(peersFileInterval):
+			case <-r.closed:
+				return
+			}
+			info, err := os.Stat(r.file)
+			if err != nil || !info.ModTime().After(modTime) {
+				continue
+			}
+			modTime = info.ModTime()
+			next, err := readPeersFile(r.file)
+			if err != nil {
+				warnf("cannot read peers, keeping the previous ones: %v", err)
+				continue
+			}
+			if reflect.DeepEqual(next, addrs) {
+				continue
+			}
+			addrs = next
+			infof("peers changed to %v", addrs)
+			select {
+			case <-peers:
+			default:
+			}
+			peers <- addrs
+		}
+	}()
+	return peers, nil
+}
+
+func (r *staticRegistry) Close() {
+	close(r.closed)
+	r.memoryRegistry.Close()
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TLSOptions struct {
+	CertFile       string     `yaml:"certFile"`
+	KeyFile        string     `yaml:"keyFile"`
+	CAFile         string     `yaml:"caFile"`
+	VerifyClient   bool       `yaml:"verifyClient"`
+	AllowedClients stringList `yaml:"allowedClients"`
+	ServerName     string     `yaml:"serverName"`
+	// how often the files are checked for changes
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+}
+
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" || o.KeyFile != ""
+}
+
+// certReloader keeps the current certificate and CA pool and swaps them when
+// the files on disk change, so certificates can be rotated without a restart.
+type certReloader struct {
+	options TLSOptions
+	cert    *tls.Certificate
+	caPool  *x509.CertPool
+	modTime time.Time
+	mux     sync.RWMutex
+}
+
+func newCertReloader(options TLSOptions) (*certReloader, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	r := &certReloader{options: options}
+	if err := r.reload(options); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (o TLSOptions) validate() error {
+	if o.CertFile == "" || o.KeyFile == "" {
+		return errors.New("both tls cert and key files are required")
+	}
+	if o.VerifyClient && o.CAFile == "" {
+		return errors.New("tls ca file is required to verify client certificates")
+	}
+	return nil
+}
+
+// update switches to new options, e.g. other files after a config reload.
+// The previous options and certificates stay in use if loading fails.
+func (r *certReloader) update(options TLSOptions) error {
+	if err := options.validate(); err != nil {
+		return err
+	}
+	return r.reload(options)
+}
+
+func (r *certReloader) reload(options TLSOptions) error {
+	cert, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+	if err != nil {
+		return fmt.Errorf("cannot load tls key pair: %v", err)
+	}
+	var caPool *x509.CertPool
+	if options.CAFile != "" {
+		pem, err := ioutil.ReadFile(options.CAFile)
+		if err != nil {
+			return fmt.Errorf("cannot read tls ca file: %v", err)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", options.CAFile)
+		}
+	}
+	r.mux.Lock()
+	r.options = options
+	r.cert = &cert
+	r.caPool = caPool
+	r.modTime = latestModTime(options)
+	r.mux.Unlock()
+	return nil
+}
+
+func (r *certReloader) currentOptions() TLSOptions {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return r.options
+}
+
+func latestModTime(options TLSOptions) time.Time {
+	var latest time.Time
+	for _, file := range []string{options.CertFile, options.KeyFile, options.CAFile} {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+func (r *certReloader) watch() {
+	for {
+		r.mux.RLock()
+		options := r.options
+		modTime := r.modTime
+		r.mux.RUnlock()
+		if options.ReloadInterval <= 0 {
+			options.ReloadInterval = time.Minute
+		}
+		time.Sleep(options.ReloadInterval)
+		if !latestModTime(options).After(modTime) {
+			continue
+		}
+		if err := r.reload(options); err != nil {
+			warnf("tls reload failed, keeping previous certificates: %v", err)
+		} else {
+			infof("tls certificates reloaded")
+		}
+	}
+}
+
+func (r *certReloader) certificate() (*tls.Certificate, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) roots() *x509.CertPool {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return r.caPool
+}
+
+func (r *certReloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			config := &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+					return r.certificate()
+				},
+			}
+			if r.currentOptions().VerifyClient {
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+				config.ClientCAs = r.roots()
+				config.VerifyPeerCertificate = r.verifyClient
+			}
+			return config, nil
+		},
+	}
+}
+
+// ClientConfig returns the config of a peer connection with the current CA
+// pool and server name, so it is taken again for every dial.
+func (r *certReloader) ClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    r.roots(),
+		ServerName: r.currentOptions().ServerName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		},
+	}
+}
+
+func (r *certReloader) verifyClient(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	allowedClients := r.currentOptions().AllowedClients
+	if len(allowedClients) == 0 {
+		return nil
+	}
+	for _, chain := range verifiedChains {
+		if len(chain) == 0 {
+			continue
+		}
+		leaf := chain[0]
+		names := append([]string{leaf.Subject.CommonName}, leaf.DNSNames...)
+		for _, name := range names {
+			for _, allowed := range allowedClients {
+				if name == allowed {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.New("client certificate is not in the allowed list")
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
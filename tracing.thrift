@@ -0,0 +1,44 @@
+// Headers of finagle's TTwitter protocol, copied from finagle-thrift's
+// tracing.thrift. Only the structs read and written by the server are kept.
+namespace go tracing
+
+struct ClientId {
+  1: string name
+}
+
+struct RequestContext {
+  1: binary key
+  2: binary value
+}
+
+struct Delegation {
+  1: string src
+  2: string dst
+}
+
+// Sent before every request once the connection is upgraded.
+struct RequestHeader {
+  1: i64 trace_id
+  2: i64 span_id
+  3: optional i64 parent_span_id
+  5: optional bool sampled
+  6: optional ClientId client_id
+  7: optional i64 flags
+  8: list<RequestContext> contexts
+  9: optional string dest
+  10: optional list<Delegation> delegations
+  11: optional i64 trace_id_high
+}
+
+// Sent before every reply once the connection is upgraded.
+struct ResponseHeader {
+  1: list<RequestContext> contexts
+}
+
+// Argument of __can__finagle__trace__v3__.
+struct ConnectionOptions {
+}
+
+// Result of __can__finagle__trace__v3__.
+struct UpgradeReply {
+}
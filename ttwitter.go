@@ -0,0 +1,152 @@
+package main
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/liusf/idgenerator/gen-go/tracing"
+)
+
+// Finagle clients call this method first. If it succeeds, every request on
+// the connection is preceded by a RequestHeader and every reply by a
+// ResponseHeader (the TTwitter protocol), otherwise they fall back to plain
+// thrift.
+const finagleUpgradeMethod = "__can__finagle__trace__v3__"
+
+var maxClientIds = 1000
+
+// requests per finagle client id, "unknown" without one
+var clientCounters = expvar.NewMap("client_requests")
+var clientIds = make(map[string]bool)
+var clientIdsMux sync.Mutex
+
+type ttwitterProcessorFactory struct {
+	processor thrift.TProcessor
+}
+
+func newTTwitterProcessorFactory(processor thrift.TProcessor) *ttwitterProcessorFactory {
+	return &ttwitterProcessorFactory{processor: processor}
+}
+
+func (p *ttwitterProcessorFactory) GetProcessor(trans thrift.TTransport) thrift.TProcessor {
+	return &ttwitterProcessor{processor: p.processor}
+}
+
+// ttwitterProcessor keeps the protocol state of one connection.
+type ttwitterProcessor struct {
+	processor thrift.TProcessor
+	upgraded  bool
+}
+
+func (p *ttwitterProcessor) Process(iprot, oprot thrift.TProtocol) (bool, thrift.TException) {
+	if p.upgraded {
+		header := tracing.NewRequestHeader()
+		if err := header.Read(iprot); err != nil {
+			return false, err
+		}
+		countClient(header)
+		if err := tracing.NewResponseHeader().Write(oprot); err != nil {
+			return false, err
+		}
+		return p.processor.Process(iprot, &traceProtocol{TProtocol: oprot, header: header})
+	}
+
+	name, typeId, seqId, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return false, err
+	}
+	if name == finagleUpgradeMethod {
+		return p.upgrade(iprot, oprot, seqId)
+	}
+	countClient(nil)
+	return p.processor.Process(&replayProtocol{TProtocol: iprot, name: name, typeId: typeId, seqId: seqId}, oprot)
+}
+
+func (p *ttwitterProcessor) upgrade(iprot, oprot thrift.TProtocol, seqId int32) (bool, thrift.TException) {
+	if err := tracing.NewConnectionOptions().Read(iprot); err != nil {
+		return false, err
+	}
+	if err := iprot.ReadMessageEnd(); err != nil {
+		return false, err
+	}
+	if err := oprot.WriteMessageBegin(finagleUpgradeMethod, thrift.REPLY, seqId); err != nil {
+		return false, err
+	}
+	if err := tracing.NewUpgradeReply().Write(oprot); err != nil {
+		return false, err
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return false, err
+	}
+	if err := oprot.Flush(); err != nil {
+		return false, err
+	}
+	p.upgraded = true
+	return true, nil
+}
+
+func countClient(header *tracing.RequestHeader) {
+	name := "unknown"
+	if header != nil && header.IsSetClientID() {
+		name = header.GetClientID().GetName()
+	}
+	clientIdsMux.Lock()
+	if !clientIds[name] {
+		if len(clientIds) >= maxClientIds {
+			name = "other"
+		} else {
+			clientIds[name] = true
+		}
+	}
+	clientIdsMux.Unlock()
+	clientCounters.Add(name, 1)
+}
+
+// replayProtocol returns a message begin already read once more.
+type replayProtocol struct {
+	thrift.TProtocol
+	name     string
+	typeId   thrift.TMessageType
+	seqId    int32
+	replayed bool
+}
+
+func (p *replayProtocol) ReadMessageBegin() (string, thrift.TMessageType, int32, error) {
+	if !p.replayed {
+		p.replayed = true
+		return p.name, p.typeId, p.seqId, nil
+	}
+	return p.TProtocol.ReadMessageBegin()
+}
+
+// traceProtocol carries the request header to the processors further down as
+// thrift has no request context. The output protocol is used because
+// TMultiplexedProcessor wraps the input one.
+type traceProtocol struct {
+	thrift.TProtocol
+	header *tracing.RequestHeader
+}
+
+// traceOf describes the finagle request being answered on oprot for logging,
+// empty for plain thrift requests.
+func traceOf(oprot thrift.TProtocol) string {
+	traced, ok := oprot.(*traceProtocol)
+	if !ok {
+		return ""
+	}
+	header := traced.header
+	traceId := fmt.Sprintf("%016x", uint64(header.GetTraceID()))
+	if header.IsSetTraceIDHigh() {
+		traceId = fmt.Sprintf("%016x%s", uint64(header.GetTraceIDHigh()), traceId)
+	}
+	s := fmt.Sprintf(" trace=%s span=%016x", traceId, uint64(header.GetSpanID()))
+	if header.IsSetParentSpanID() {
+		s += fmt.Sprintf(" parent=%016x", uint64(header.GetParentSpanID()))
+	}
+	if header.IsSetClientID() {
+		s += " client=" + header.GetClientID().GetName()
+	}
+	return s
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	errNoNode     = errors.New("node does not exist")
+	errNodeExists = errors.New("node already exists")
+	errBadVersion = errors.New("node was changed by someone else")
+)
+
+// leaseHeldError refuses a worker id whose lease another node holds and
+// has not let expire, by the clock of the node claiming it.
+type leaseHeldError struct {
+	workerId    int64
+	owner       string
+	leaseExpiry int64
+}
+
+func (e *leaseHeldError) Error() string {
+	return fmt.Sprintf("worker id %d is leased by %s until %d", e.workerId, e.owner, e.leaseExpiry)
+}
+
+// workerStore is what claiming worker ids needs from a registry. Nodes
+// are addressed by their zookeeper path, etcd uses it as key.
+type workerStore interface {
+	// createEphemeral creates a node which goes away with the session.
+	createEphemeral(path string, data []byte) error
+	// create creates a persistent node.
+	create(path string, data []byte) error
+	get(path string) (data []byte, version int64, err error)
+	// set replaces the data if the node is still at version and returns the
+	// new version.
+	set(path string, data []byte, version int64) (int64, error)
+	delete(path string) error
+}
+
+// workerLease is the persistent record <basePath>/idgenerators/leases/<dc>/<id>
+// of a worker id, kept after its holder is gone. The holder renews it while
+// serving and never issues ids at or after LeaseExpiry, so whoever takes the
+// worker id over next waits for its own clock to pass LeaseExpiry. Epoch
+// fences the holders, every claim increments it and a holder finding another
+// epoch stops.
+type workerLease struct {
+	Owner string `json:"owner"`
+	Epoch int64  `json:"epoch"`
+	// milliseconds since 1970 by the clock of the holder
+	LeaseExpiry   int64 `json:"leaseExpiry"`
+	LastTimestamp int64 `json:"lastTimestamp"`
+}
+
+// workerClaim holds a worker id and its lease. With an automatically assigned
+// id it also holds the ephemeral node <basePath>/idgenerators/workers/<dc>/<id>
+// which marks the id as in use, it goes away with the session if the process
+// dies without releasing it.
+type workerClaim struct {
+	store        workerStore
+	path         string
+	leasePath    string
+	workerId     int64
+	owner        string
+	duration     time.Duration
+	notBefore    int64
+	leaseExpiry  int64
+	leaseVersion int64
+	epoch        int64
+	lost         bool
+	// closed when the claim is lost
+	lostChan chan bool
+	// told when ids may be issued, once keepAlive runs
+	handler *IdGeneratorHandler
+	quit    chan bool
+	mux     sync.Mutex
+}
+
+func workersPath(basePath string, datacenterId int64) string {
+	return fmt.Sprintf("%s/idgenerators/workers/%d", basePath, datacenterId)
+}
+
+func leasesPath(basePath string, datacenterId int64) string {
+	return fmt.Sprintf("%s/idgenerators/leases/%d", basePath, datacenterId)
+}
+
+// claimWorkerId takes the lowest worker id of the datacenter nobody else
+// holds and its lease, or only workerId unless it is negative. owner is
+// stored in the nodes to tell who has an id.
+func claimWorkerId(store workerStore, basePath string, datacenterId int64, workerId int64, owner string, duration time.Duration) (*workerClaim, error) {
+	dir := workersPath(basePath, datacenterId)
+	for id := int64(0); id <= maxWorkerId; id++ {
+		if workerId >= 0 && id != workerId {
+			continue
+		}
+		path := dir + "/" + strconv.FormatInt(id, 10)
+		err := store.createEphemeral(path, []byte(owner))
+		if err == errNodeExists && workerId >= 0 {
+			return nil, fmt.Errorf("worker id %d of datacenter %d is taken (see %s)", workerId, datacenterId, path)
+		}
+		if err == errNodeExists {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot create %s: %v", path, err)
+		}
+		claim := newWorkerClaim(store, basePath, datacenterId, id, owner, duration)
+		claim.path = path
+		if err := claim.acquire(); err != nil {
+			store.delete(path)
+			// the holder lost its node but may still issue ids until its
+			// lease expires
+			if _, held := err.(*leaseHeldError); held && workerId < 0 {
+				infof("skipping worker id %d: %v", id, err)
+				continue
+			}
+			return nil, err
+		}
+		return claim, nil
+	}
+	return nil, fmt.Errorf("all %d worker ids of datacenter %d are taken (see %s)", maxWorkerId+1, datacenterId, dir)
+}
+
+// leaseWorkerId takes the lease of a worker id given on the command line.
+func leaseWorkerId(store workerStore, basePath string, datacenterId int64, workerId int64, owner string, duration time.Duration) (*workerClaim, error) {
+	claim := newWorkerClaim(store, basePath, datacenterId, workerId, owner, duration)
+	if err := claim.acquire(); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+func newWorkerClaim(store workerStore, basePath string, datacenterId int64, workerId int64, owner string, duration time.Duration) *workerClaim {
+	return &workerClaim{
+		store:     store,
+		leasePath: leasesPath(basePath, datacenterId) + "/" + strconv.FormatInt(workerId, 10),
+		workerId:  workerId,
+		owner:     owner,
+		duration:  duration,
+		quit:      make(chan bool),
+		lostChan:  make(chan bool),
+	}
+}
+
+// acquire reads the lease left by the previous holder and replaces it with
+// ours, retrying if the previous holder renews it meanwhile. A lease of
+// another owner is only taken over once it expired, a lease of ours is left
+// by an earlier run of this node.
+func (c *workerClaim) acquire() error {
+	for {
+		data, version, err := c.store.get(c.leasePath)
+		if err == errNoNode {
+			c.epoch = 1
+			lease := c.newLease()
+			data, _ := json.Marshal(lease)
+			err := c.store.create(c.leasePath, data)
+			if err == errNodeExists {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("cannot create %s: %v", c.leasePath, err)
+			}
+			if _, c.leaseVersion, err = c.store.get(c.leasePath); err != nil {
+				return fmt.Errorf("cannot read %s: %v", c.leasePath, err)
+			}
+			c.leaseExpiry = lease.LeaseExpiry
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %v", c.leasePath, err)
+		}
+		var previous workerLease
+		if err := json.Unmarshal(data, &previous); err != nil {
+			return fmt.Errorf("invalid lease %s: %v", c.leasePath, err)
+		}
+		if previous.Owner != c.owner && previous.LeaseExpiry > getTimestamp() {
+			return &leaseHeldError{workerId: c.workerId, owner: previous.Owner, leaseExpiry: previous.LeaseExpiry}
+		}
+		c.epoch = previous.Epoch + 1
+		lease := c.newLease()
+		data, _ = json.Marshal(lease)
+		version, err = c.store.set(c.leasePath, data, version)
+		if err == errBadVersion {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("cannot write %s: %v", c.leasePath, err)
+		}
+		c.notBefore = previous.LeaseExpiry
+		if previous.LastTimestamp > c.notBefore {
+			c.notBefore = previous.LastTimestamp
+		}
+		c.leaseExpiry = lease.LeaseExpiry
+		c.leaseVersion = version
+		if ahead := c.notBefore - getTimestamp(); ahead >= 0 {
+			warnf("worker id %d was held by %s until %d, %d ms ahead of our clock, no ids until then",
+				c.workerId, previous.Owner, c.notBefore, ahead+1)
+		}
+		return nil
+	}
+}
+
+func (c *workerClaim) newLease() workerLease {
+	now := getTimestamp()
+	return workerLease{Owner: c.owner, Epoch: c.epoch, LastTimestamp: now, LeaseExpiry: now + int64(c.duration/time.Millisecond)}
+}
+
+// keepAlive renews the lease right away and then a few times per lease
+// duration, and tells the handler for how long it may issue ids. Each renewal
+// confirms that the epoch is still ours, without it the handler stops when the
+// lease expires, at most the lease duration after the last confirmation. It
+// stops renewing once another epoch was written.
+func (c *workerClaim) keepAlive(handler *IdGeneratorHandler) {
+	c.mux.Lock()
+	c.handler = handler
+	c.mux.Unlock()
+	ticker := time.NewTicker(c.duration / 3)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		c.mux.Lock()
+		select {
+		case <-c.quit:
+			c.mux.Unlock()
+			return
+		default:
+		}
+		lease := c.newLease()
+		err := c.renew(lease)
+		if err == nil {
+			c.leaseExpiry = lease.LeaseExpiry
+			handler.setValidity(c.notBefore, c.leaseExpiry)
+		} else if err == errBadVersion || err == errNoNode {
+			c.lose(fmt.Sprintf("epoch %d was superseded", c.epoch))
+			c.mux.Unlock()
+			return
+		} else {
+			warnf("cannot renew lease of worker id %d, no ids after %d: %v", c.workerId, c.leaseExpiry, err)
+		}
+		c.mux.Unlock()
+	}
+}
+
+// renew writes lease if the lease still has our epoch. A write of ours
+// which seemed to fail may have changed the version, then the version read
+// back is taken. The lock must be held.
+func (c *workerClaim) renew(lease workerLease) error {
+	data, _ := json.Marshal(lease)
+	version, err := c.store.set(c.leasePath, data, c.leaseVersion)
+	if err == errBadVersion {
+		var current workerLease
+		data, version, err := c.store.get(c.leasePath)
+		if err != nil {
+			return err
+		}
+		if json.Unmarshal(data, &current) != nil || current.Epoch != c.epoch || current.Owner != c.owner {
+			return errBadVersion
+		}
+		c.leaseVersion = version
+		return c.renew(lease)
+	}
+	if err != nil {
+		return err
+	}
+	c.leaseVersion = version
+	return nil
+}
+
+// Epoch is the fencing epoch of the claim.
+func (c *workerClaim) Epoch() int64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.epoch
+}
+
+// lose gives up the worker id for good after someone else took it, the lock
+// must be held.
+func (c *workerClaim) lose(reason string) {
+	errorf("lost worker id %d, %s", c.workerId, reason)
+	c.lost = true
+	close(c.lostChan)
+	if c.handler != nil {
+		c.handler.suspend("claim", fmt.Sprintf("lost worker id %d, %s", c.workerId, reason))
+	}
+}
+
+// Lost is closed when someone else took the worker id.
+func (c *workerClaim) Lost() <-chan bool {
+	return c.lostChan
+}
+
+// sessionLost stops issuing ids after the session holding the claim
+// expired, until reclaim finds the worker id still ours.
+func (c *workerClaim) sessionLost() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.handler != nil && !c.lost {
+		c.handler.suspend("claim", fmt.Sprintf("session holding worker id %d expired", c.workerId))
+	}
+}
+
+// reclaim takes the worker id again with a new session. The id is lost if
+// another node claimed it or wrote its lease meanwhile, an error means it is
+// worth trying again.
+func (c *workerClaim) reclaim() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	select {
+	case <-c.quit:
+		return nil
+	default:
+	}
+	if c.lost {
+		return nil
+	}
+	if c.path != "" {
+		err := c.store.createEphemeral(c.path, []byte(c.owner))
+		if err == errNodeExists {
+			var data []byte
+			if data, _, err = c.store.get(c.path); err == nil && string(data) != c.owner {
+				c.lose(fmt.Sprintf("claimed by %s", data))
+				return nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("cannot claim worker id %d again: %v", c.workerId, err)
+		}
+	}
+	data, version, err := c.store.get(c.leasePath)
+	var lease workerLease
+	if err == errNoNode || err == nil && (json.Unmarshal(data, &lease) != nil || lease.Epoch != c.epoch || lease.Owner != c.owner) {
+		c.lose(fmt.Sprintf("epoch %d was superseded", c.epoch))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read lease of worker id %d: %v", c.workerId, err)
+	}
+	c.leaseVersion = version
+	if c.handler != nil {
+		c.handler.resume("claim")
+	}
+	infof("worker id %d claimed again", c.workerId)
+	return nil
+}
+
+// Release ends the lease at lastTimestamp, the last timestamp the handler
+// issued ids for, so the next holder need not wait for the lease to expire,
+// and gives an automatically assigned worker id back right away instead of
+// when the session times out.
+func (c *workerClaim) Release(lastTimestamp int64) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	close(c.quit)
+	if !c.lost {
+		if lastTimestamp < c.notBefore {
+			lastTimestamp = c.notBefore
+		}
+		data, _ := json.Marshal(workerLease{Owner: c.owner, Epoch: c.epoch, LastTimestamp: lastTimestamp, LeaseExpiry: lastTimestamp})
+		if _, err := c.store.set(c.leasePath, data, c.leaseVersion); err != nil {
+			warnf("cannot end lease of worker id %d: %v", c.workerId, err)
+		}
+	}
+	if c.path != "" {
+		if err := c.store.delete(c.path); err != nil && err != errNoNode {
+			warnf("cannot release worker id %d: %v", c.workerId, err)
+		} else {
+			infof("released worker id %d", c.workerId)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liusf/idgenerator/gen-go/idgenerator"
+)
+
+func readLease(t *testing.T, store workerStore, path string) workerLease {
+	t.Helper()
+	data, _, err := store.get(path)
+	if err != nil {
+		t.Fatalf("cannot read lease %s: %v", path, err)
+	}
+	var lease workerLease
+	if err := json.Unmarshal(data, &lease); err != nil {
+		t.Fatalf("invalid lease %s: %v", path, err)
+	}
+	return lease
+}
+
+// supersede writes the lease of path with the next epoch for owner, as a
+// node taking it over does.
+func supersede(t *testing.T, store workerStore, path string, owner string) workerLease {
+	t.Helper()
+	lease := readLease(t, store, path)
+	lease.Owner = owner
+	lease.Epoch++
+	data, _ := json.Marshal(lease)
+	_, version, _ := store.get(path)
+	if _, err := store.set(path, data, version); err != nil {
+		t.Fatalf("cannot write lease %s: %v", path, err)
+	}
+	return lease
+}
+
+func TestClaimWorkerIdExhausted(t *testing.T) {
+	r := newMemoryRegistry("/test")
+	for id := int64(0); id <= maxWorkerId; id++ {
+		claim, err := r.join().ClaimWorkerId(1, -1, true, fmt.Sprintf("10.0.0.1:%d", 3456+id), time.Minute)
+		if err != nil {
+			t.Fatalf("claim %d: %v", id, err)
+		}
+		if claim.workerId != id {
+			t.Fatalf("claimed worker id %d, expected %d", claim.workerId, id)
+		}
+	}
+	_, err := r.join().ClaimWorkerId(1, -1, true, "10.0.0.2:3456", time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "are taken") {
+		t.Fatalf("claimed a worker id with all taken, error %v", err)
+	}
+	// the other datacenter has its own ids
+	if claim, err := r.join().ClaimWorkerId(2, -1, true, "10.0.0.2:3456", time.Minute); err != nil || claim.workerId != 0 {
+		t.Fatalf("claim in datacenter 2: %v", err)
+	}
+}
+
+func TestClaimWorkerIdTakeover(t *testing.T) {
+	r1 := newMemoryRegistry("/test")
+	c1, err := r1.ClaimWorkerId(1, -1, true, "10.0.0.1:3456", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the node dies, its worker id node goes with the session but the lease
+	// is live
+	r1.Close()
+
+	r2 := r1.join()
+	c2, err := r2.ClaimWorkerId(1, -1, true, "10.0.0.2:3456", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c2.workerId != 1 {
+		t.Fatalf("claimed worker id %d, expected 1 as the lease of 0 is live", c2.workerId)
+	}
+	_, err = r2.ClaimWorkerId(1, 0, false, "10.0.0.2:3457", time.Minute)
+	if held, ok := err.(*leaseHeldError); !ok || held.owner != "10.0.0.1:3456" {
+		t.Fatalf("took over the live lease of worker id 0, error %v", err)
+	}
+	if lease := readLease(t, r2, c1.leasePath); lease.Epoch != 1 || lease.Owner != "10.0.0.1:3456" {
+		t.Fatalf("lease %+v was written by a refused claim", lease)
+	}
+
+	// the same node restarted takes its own lease over, after its end
+	r3 := r1.join()
+	c3, err := r3.ClaimWorkerId(1, 0, true, "10.0.0.1:3456", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c3.Epoch() != 2 {
+		t.Fatalf("epoch %d after taking over epoch 1", c3.Epoch())
+	}
+	if c3.notBefore != c1.leaseExpiry {
+		t.Fatalf("no ids before %d, expected the end of the previous lease %d", c3.notBefore, c1.leaseExpiry)
+	}
+}
+
+func TestClaimWorkerIdReleased(t *testing.T) {
+	r := newMemoryRegistry("/test")
+	c1, err := r.ClaimWorkerId(1, 0, false, "10.0.0.1:3456", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a released lease is taken over right away, after its last timestamp
+	last := getTimestamp()
+	c1.Release(last)
+	c2, err := r.join().ClaimWorkerId(1, 0, false, "10.0.0.2:3456", time.Minute)
+	if err != nil {
+		t.Fatalf("cannot take over a released lease: %v", err)
+	}
+	if c2.Epoch() != 2 || c2.notBefore != last {
+		t.Fatalf("epoch %d and no ids before %d, expected epoch 2 and %d", c2.Epoch(), c2.notBefore, last)
+	}
+}
+
+func TestClaimWorkerIdExpired(t *testing.T) {
+	r := newMemoryRegistry("/test")
+	c1, err := r.ClaimWorkerId(1, 0, false, "10.0.0.1:3456", 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the holder died without releasing the lease
+	time.Sleep(20 * time.Millisecond)
+	c2, err := r.join().ClaimWorkerId(1, -1, true, "10.0.0.2:3456", time.Minute)
+	if err != nil {
+		t.Fatalf("cannot take over an expired lease: %v", err)
+	}
+	if c2.workerId != 0 || c2.Epoch() != 2 || c2.notBefore != c1.leaseExpiry {
+		t.Fatalf("worker id %d, epoch %d and no ids before %d, expected 0, 2 and %d",
+			c2.workerId, c2.Epoch(), c2.notBefore, c1.leaseExpiry)
+	}
+}
+
+func TestKeepAliveEpochLost(t *testing.T) {
+	r1 := newMemoryRegistry("/test")
+	c1, err := r1.ClaimWorkerId(1, -1, true, "10.0.0.1:3456", 30*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, _ := NewIdGeneratorHandler(c1.workerId, 1)
+	handler.setValidity(c1.notBefore, c1.leaseExpiry)
+	go c1.keepAlive(handler)
+	_, first := validity(handler)
+	waitFor(t, "a renewal", func() bool {
+		_, notAfter := validity(handler)
+		return notAfter > first
+	})
+	if _, err := handler.GetId("ORDER"); err != nil {
+		t.Fatalf("no id with a renewed lease: %v", err)
+	}
+
+	// another node writes its epoch, e.g. it took the lease over while this
+	// one was cut off
+	lease := supersede(t, r1.join(), c1.leasePath, "10.0.0.2:3456")
+	select {
+	case <-c1.Lost():
+	case <-time.After(5 * time.Second):
+		t.Fatal("claim not lost after another epoch was written")
+	}
+	if _, err := handler.GetId("ORDER"); err == nil {
+		t.Fatal("issued an id after the claim was lost")
+	} else if _, ok := err.(*idgenerator.ServiceUnavailable); !ok {
+		t.Fatalf("error %v after the claim was lost, expected ServiceUnavailable", err)
+	}
+	// the lease of the other node is left alone
+	if current := readLease(t, r1, c1.leasePath); current != lease {
+		t.Fatalf("lease %+v changed after the claim was lost, expected %+v", current, lease)
+	}
+	c1.Release(-1)
+	if current := readLease(t, r1, c1.leasePath); current != lease {
+		t.Fatalf("lease %+v changed by releasing a lost claim, expected %+v", current, lease)
+	}
+}
+
+func TestReclaim(t *testing.T) {
+	r1 := newMemoryRegistry("/test")
+	c1, err := r1.ClaimWorkerId(1, -1, true, "10.0.0.1:3456", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, _ := NewIdGeneratorHandler(c1.workerId, 1)
+	handler.setValidity(c1.notBefore, c1.leaseExpiry)
+	c1.handler = handler
+
+	// the session expired and took the worker id node along
+	r1.delete(c1.path)
+	c1.sessionLost()
+	if reason := handler.Unavailable(); !strings.Contains(reason, "session holding worker id 0 expired") {
+		t.Fatalf("handler unavailable %q after the session expired", reason)
+	}
+	if err := c1.reclaim(); err != nil {
+		t.Fatal(err)
+	}
+	if reason := handler.Unavailable(); reason != "" {
+		t.Fatalf("handler unavailable %q after the worker id was claimed again", reason)
+	}
+	if data, _, err := r1.get(c1.path); err != nil || string(data) != "10.0.0.1:3456" {
+		t.Fatalf("worker id node %q not created again: %v", data, err)
+	}
+
+	// another node claimed the worker id while the session was gone
+	r1.delete(c1.path)
+	c1.sessionLost()
+	if err := r1.join().createEphemeral(c1.path, []byte("10.0.0.2:3456")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c1.reclaim(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c1.Lost():
+	default:
+		t.Fatal("claim not lost after another node claimed the worker id")
+	}
+	if reason := handler.Unavailable(); !strings.Contains(reason, "claimed by 10.0.0.2:3456") {
+		t.Fatalf("handler unavailable %q after the worker id was claimed by another node", reason)
+	}
+}
+
+func TestReclaimEpochLost(t *testing.T) {
+	r1 := newMemoryRegistry("/test")
+	c1, err := r1.ClaimWorkerId(1, -1, true, "10.0.0.1:3456", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r1.delete(c1.path)
+	c1.sessionLost()
+	// the lease was taken over while the session was gone
+	supersede(t, r1.join(), c1.leasePath, "10.0.0.2:3456")
+	if err := c1.reclaim(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c1.Lost():
+	default:
+		t.Fatal("claim not lost after another epoch took the lease")
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/liusf/idgenerator/internal/serverset"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+var zkSessionTimeout = 10 * time.Second
+
+// zkRegistry registers the node in the serverset <basePath>/idgenerators
+// like finagle services and claims worker ids with its own zk session. When
+// the session expires the member node and the worker id claims are created
+// again with the next session.
+type zkRegistry struct {
+	*zkWorkerStore
+	basePath string
+	// the registration, nil when not registered
+	registration *serversetMember
+	// the member node, empty while not in the serverset
+	member string
+	// the session expired and the registration is not back yet
+	expired bool
+	claims  []*workerClaim
+	closed  chan bool
+	mux     sync.Mutex
+}
+
+func newZkRegistry(zkServers []string, basePath string) (*zkRegistry, error) {
+	store, err := newZkWorkerStore(zkServers)
+	if err != nil {
+		return nil, err
+	}
+	r := &zkRegistry{zkWorkerStore: store, basePath: basePath, closed: make(chan bool)}
+	go r.watchSession()
+	return r, nil
+}
+
+func (r *zkRegistry) serversetPath() string {
+	return r.basePath + "/idgenerators"
+}
+
+func (r *zkRegistry) Register(member serversetMember) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	member.Status = statusAlive
+	r.registration = &member
+	if err := r.createMember(); err != nil {
+		r.registration = nil
+		return err
+	}
+	return nil
+}
+
+// createMember adds the node to the serverset, the lock must be held.
+func (r *zkRegistry) createMember() error {
+	data, _ := json.Marshal(r.registration)
+	prefix := r.serversetPath() + "/member_"
+	if err := r.createParents(prefix); err != nil {
+		return err
+	}
+	path, err := r.conn.Create(prefix, data, zk.FlagEphemeral|zk.FlagSequence, zk.WorldACL(zk.PermAll))
+	if err != nil {
+		return err
+	}
+	r.member = path
+	return nil
+}
+
+func (r *zkRegistry) Deregister() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.registration = nil
+	if r.member == "" {
+		return nil
+	}
+	if err := r.conn.Delete(r.member, -1); err != nil && err != zk.ErrNoNode {
+		return err
+	}
+	r.member = ""
+	return nil
+}
+
+func (r *zkRegistry) SetStatus(status string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.registration == nil {
+		return nil
+	}
+	r.registration.Status = status
+	if r.member == "" {
+		return nil
+	}
+	data, _ := json.Marshal(r.registration)
+	_, err := r.conn.Set(r.member, data, -1)
+	return err
+}
+
+func (r *zkRegistry) Registered() bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.registration != nil && !r.expired
+}
+
+// watchSession registers the node and claims the worker ids again when a
+// session follows one that expired, retrying until it works.
+func (r *zkRegistry) watchSession() {
+	for event := range r.events {
+		switch event.State {
+		case zk.StateExpired:
+			errorf("zk session expired, registration and worker id claims are lost until the next session")
+			r.mux.Lock()
+			r.expired = true
+			r.member = ""
+			claims := r.claims
+			r.mux.Unlock()
+			for _, claim := range claims {
+				claim.sessionLost()
+			}
+		case zk.StateHasSession:
+			r.mux.Lock()
+			expired := r.expired
+			r.mux.Unlock()
+			for expired {
+				if err := r.restore(); err != nil {
+					warnf("%v, trying again", err)
+					select {
+					case <-time.After(time.Second):
+						continue
+					case <-r.closed:
+						return
+					}
+				}
+				expired = false
+			}
+		}
+	}
+}
+
+// restore claims the worker ids again and then registers the node.
+func (r *zkRegistry) restore() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lost := false
+	for _, claim := range r.claims {
+		if err := claim.reclaim(); err != nil {
+			return err
+		}
+		select {
+		case <-claim.Lost():
+			lost = true
+		default:
+		}
+	}
+	if r.registration != nil && !lost {
+		if err := r.createMember(); err != nil {
+			return fmt.Errorf("cannot register again: %v", err)
+		}
+		infof("registered again as %s", r.member)
+	}
+	r.expired = false
+	return nil
+}
+
+func (r *zkRegistry) Peers() ([]string, error) {
+	dir := r.serversetPath()
+	if err := r.createParents(dir + "/member_"); err != nil {
+		return nil, err
+	}
+	return serverset.Members(r.conn, dir, serversetLogger{})
+}
+
+func (r *zkRegistry) Watch() (<-chan []string, error) {
+	dir := r.serversetPath()
+	if err := r.createParents(dir + "/member_"); err != nil {
+		return nil, err
+	}
+	watch := serverset.NewWatch(r.conn, dir, serversetLogger{}, r.closed)
+	addrs, err := watch.Members()
+	if err != nil {
+		return nil, err
+	}
+	peers := make(chan []string, 1)
+	peers <- addrs
+	go func() {
+		defer close(peers)
+		watch.Run(addrs, func(addrs []string) {
+			select {
+			case <-peers:
+			default:
+			}
+			peers <- addrs
+		})
+	}()
+	return peers, nil
+}
+
+func (r *zkRegistry) ClaimWorkerId(datacenterId int64, workerId int64, auto bool, owner string, lease time.Duration) (*workerClaim, error) {
+	claim, err := takeWorkerId(r, r.basePath, datacenterId, workerId, auto, owner, lease)
+	if err != nil {
+		return nil, err
+	}
+	r.mux.Lock()
+	r.claims = append(r.claims, claim)
+	r.mux.Unlock()
+	return claim, nil
+}
+
+func (r *zkRegistry) Close() {
+	r.Deregister()
+	close(r.closed)
+	r.conn.Close()
+}
+
+// zkLogger sends the chatty connection logs of the zk client to debug.
+type zkLogger struct{}
+
+func (zkLogger) Printf(format string, args ...interface{}) {
+	debugf("zk: "+format, args...)
+}
+
+// serversetLogger sends the problems reading the serverset to warn.
+type serversetLogger struct{}
+
+func (serversetLogger) Printf(format string, args ...interface{}) {
+	warnf(format, args...)
+}
+
+type zkWorkerStore struct {
+	conn   *zk.Conn
+	events <-chan zk.Event
+}
+
+func newZkWorkerStore(zkServers []string) (*zkWorkerStore, error) {
+	conn, events, err := zk.Connect(zkServers, zkSessionTimeout, zk.WithLogger(zkLogger{}))
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.After(zkSessionTimeout)
+	for {
+		select {
+		case event := <-events:
+			if event.State == zk.StateHasSession {
+				return &zkWorkerStore{conn: conn, events: events}, nil
+			}
+		case <-timeout:
+			conn.Close()
+			return nil, fmt.Errorf("cannot connect to zk servers %v", zkServers)
+		}
+	}
+}
+
+func zkError(err error) error {
+	switch err {
+	case zk.ErrNoNode:
+		return errNoNode
+	case zk.ErrNodeExists:
+		return errNodeExists
+	case zk.ErrBadVersion:
+		return errBadVersion
+	}
+	return err
+}
+
+// createParents creates the persistent znodes on the way to the parent of path.
+func (s *zkWorkerStore) createParents(path string) error {
+	parent := ""
+	names := strings.Split(strings.Trim(path, "/"), "/")
+	for _, name := range names[:len(names)-1] {
+		parent += "/" + name
+		_, err := s.conn.Create(parent, nil, 0, zk.WorldACL(zk.PermAll))
+		if err != nil && err != zk.ErrNodeExists {
+			return fmt.Errorf("cannot create %s: %v", parent, err)
+		}
+	}
+	return nil
+}
+
+func (s *zkWorkerStore) createEphemeral(path string, data []byte) error {
+	if err := s.createParents(path); err != nil {
+		return err
+	}
+	_, err := s.conn.Create(path, data, zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
+	return zkError(err)
+}
+
+func (s *zkWorkerStore) create(path string, data []byte) error {
+	if err := s.createParents(path); err != nil {
+		return err
+	}
+	_, err := s.conn.Create(path, data, 0, zk.WorldACL(zk.PermAll))
+	return zkError(err)
+}
+
+func (s *zkWorkerStore) get(path string) ([]byte, int64, error) {
+	data, stat, err := s.conn.Get(path)
+	if err != nil {
+		return nil, 0, zkError(err)
+	}
+	return data, int64(stat.Version), nil
+}
+
+func (s *zkWorkerStore) set(path string, data []byte, version int64) (int64, error) {
+	stat, err := s.conn.Set(path, data, int32(version))
+	if err != nil {
+		return 0, zkError(err)
+	}
+	return int64(stat.Version), nil
+}
+
+func (s *zkWorkerStore) delete(path string) error {
+	return zkError(s.conn.Delete(path, -1))
+}